@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// auditCrossService memeriksa hasil akhir (setelah rebalance) dan
+// mengembalikan setiap orang yang muncul di lebih dari satu ibadah pada
+// tanggal yang sama, mis. "03-08-2025: Pnt. X bertugas di 07.00, 10.00".
+func auditCrossService(assign Assignment, dates []time.Time, maps []RoleMap) []string {
+	same := map[string]bool{} // role SamePerson: lintas ibadah memang disengaja
+	for _, m := range maps {
+		if m.SamePerson {
+			same[m.Role] = true
+		}
+	}
+	var res []string
+	for _, d := range dates {
+		svcOf := map[string][]string{}
+		for _, svc := range sortedServices(assign[d]) {
+			seen := map[string]bool{}
+			for role, names := range assign[d][svc] {
+				if same[role] {
+					continue
+				}
+				for _, n := range names {
+					if !seen[n] {
+						seen[n] = true
+						svcOf[n] = append(svcOf[n], serviceName(svc, "."))
+					}
+				}
+			}
+		}
+		names := make([]string, 0, len(svcOf))
+		for n, svcs := range svcOf {
+			if len(svcs) > 1 {
+				names = append(names, n)
+			}
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			res = append(res, fmt.Sprintf("%s: %s bertugas di %s", d.Format("02-01-2006"), n, strings.Join(svcOf[n], ", ")))
+		}
+	}
+	return res
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// ==================== Warna terminal (-color) ====================
+
+const (
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiReset  = "\x1b[0m"
+)
+
+var colorOn bool
+
+// initColor membaca -color: auto aktif hanya bila stdout terminal dan
+// NO_COLOR tidak diset; always/never memaksa nyala/mati.
+func initColor(mode string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", "auto":
+		colorOn = os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+	case "always":
+		colorOn = true
+	case "never":
+		colorOn = false
+	default:
+		return fmt.Errorf("-color harus auto, always, atau never, dapat %q", mode)
+	}
+	return nil
+}
+
+func isTerminal(f *os.File) bool {
+	st, err := f.Stat()
+	return err == nil && st.Mode()&os.ModeCharDevice != 0
+}
+
+// paint membungkus s dengan kode warna bila -color aktif; newline di akhir
+// tetap di luar warna. Aman untuk string format (kode ANSI tanpa %).
+func paint(code, s string) string {
+	if !colorOn {
+		return s
+	}
+	body := strings.TrimRight(s, "\n")
+	return code + body + ansiReset + s[len(body):]
+}
+
+// colorKind mewarnai baris log scheduler menurut jenisnya (Config.Paint):
+// slot kosong merah, pick relax & WARN kuning, pick lain hijau.
+func colorKind(kind scheduler.LogKind, line string) string {
+	switch kind {
+	case scheduler.LogEmpty:
+		return paint(ansiRed, line)
+	case scheduler.LogRelax, scheduler.LogWarn:
+		return paint(ansiYellow, line)
+	case scheduler.LogPick:
+		return paint(ansiGreen, line)
+	}
+	return line
+}
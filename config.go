@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// applyConfigFile membaca file konfigurasi (.json atau .yaml/.yml) dan
+// mengisi flag yang namanya sama dengan kunci. Flag yang diberikan eksplisit
+// di command line tetap menang. Kunci yang bukan nama flag (salah ketik)
+// adalah error, lengkap dengan nomor barisnya.
+func applyConfigFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	var raw map[string]interface{}
+	switch ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.UseNumber() // seed int64 jangan lewat float64
+		err = dec.Decode(&raw)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &raw)
+	default:
+		return errors.New("ekstensi config harus .json, .yaml, atau .yml")
+	}
+	if err != nil {
+		return err
+	}
+	lines := configKeyLines(b, ext)
+	at := func(key string) string {
+		if n := lines[key]; n > 0 {
+			return fmt.Sprintf("%s (baris %d)", key, n)
+		}
+		return key
+	}
+	if err := checkConfigKeys(raw, lines); err != nil {
+		return err
+	}
+
+	explicit := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for key, v := range raw {
+		if key == "services" {
+			defs, err := parseServicesConfig(v)
+			if err != nil {
+				return fmt.Errorf("%w (baris %d)", err, lines[key])
+			}
+			serviceDefs = defs
+			continue
+		}
+		if key == "config" || explicit[key] {
+			continue
+		}
+		// daftar (mis. dates, kolektanPatternOn) digabung dengan koma;
+		// flag berulang (-pin, -labelDates) di-Set per item
+		if list, ok := v.([]interface{}); ok {
+			parts := make([]string, 0, len(list))
+			for _, item := range list {
+				switch item.(type) {
+				case map[string]interface{}, []interface{}, nil:
+					return fmt.Errorf("kunci %s: isi daftar harus string/angka", at(key))
+				}
+				parts = append(parts, fmt.Sprint(item))
+			}
+			if _, ok := flag.Lookup(key).Value.(repeatedFlag); ok {
+				for _, p := range parts {
+					if err := flag.Set(key, p); err != nil {
+						return fmt.Errorf("nilai %s tidak valid: %w", at(key), err)
+					}
+				}
+				continue
+			}
+			v = strings.Join(parts, ",")
+		}
+		switch v.(type) {
+		case map[string]interface{}, nil:
+			return fmt.Errorf("kunci %s: nilai harus string/angka/boolean/daftar", at(key))
+		}
+		if err := flag.Set(key, fmt.Sprint(v)); err != nil {
+			return fmt.Errorf("nilai %s tidak valid: %w", at(key), err)
+		}
+	}
+	return nil
+}
+
+// checkConfigKeys: semua kunci tingkat atas harus nama flag (atau "services");
+// kunci tak dikenal dilaporkan sekaligus, urut baris, dengan saran ejaan.
+func checkConfigKeys(raw map[string]interface{}, lines map[string]int) error {
+	var unknown []string
+	for key := range raw {
+		if key != "services" && flag.Lookup(key) == nil {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Slice(unknown, func(i, j int) bool {
+		if lines[unknown[i]] != lines[unknown[j]] {
+			return lines[unknown[i]] < lines[unknown[j]]
+		}
+		return unknown[i] < unknown[j]
+	})
+	msgs := make([]string, len(unknown))
+	for i, key := range unknown {
+		msg := fmt.Sprintf("%q", key)
+		if n := lines[key]; n > 0 {
+			msg += fmt.Sprintf(" (baris %d)", n)
+		}
+		if s := suggestFlag(key); s != "" {
+			msg += fmt.Sprintf(", maksudnya %q?", s)
+		}
+		msgs[i] = msg
+	}
+	return fmt.Errorf("kunci config tidak dikenal:\n  %s", strings.Join(msgs, "\n  "))
+}
+
+// suggestFlag: nama flag yang paling mirip key (beda huruf besar/kecil atau
+// maksimal 2 huruf), kosong bila tidak ada.
+func suggestFlag(key string) string {
+	best, bestDist := "", 3
+	flag.VisitAll(func(f *flag.Flag) {
+		d := editDistance(strings.ToLower(key), strings.ToLower(f.Name))
+		if d < bestDist {
+			best, bestDist = f.Name, d
+		}
+	})
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// configKeyLines: nomor baris tiap kunci tingkat atas file config, untuk
+// pesan error. Kunci yang tidak ketemu (mis. file rusak) bernilai 0.
+func configKeyLines(b []byte, ext string) map[string]int {
+	lines := map[string]int{}
+	if ext == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+			return lines
+		}
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return lines
+			}
+			key, _ := tok.(string)
+			lines[key] = bytes.Count(b[:dec.InputOffset()], []byte("\n")) + 1
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return lines
+			}
+		}
+		return lines
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err != nil || len(doc.Content) == 0 {
+		return lines
+	}
+	m := doc.Content[0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		lines[m.Content[i].Value] = m.Content[i].Line
+	}
+	return lines
+}
+
+// repeatedFlag: flag berulang yang item-nya boleh berisi koma (-pin,
+// -labelDates); di file config ditulis sebagai daftar, satu Set per item.
+type repeatedFlag interface {
+	flag.Value
+	items() []string
+}
+
+// printEffectiveConfig menulis nilai akhir semua flag (file -config ditimpa
+// command line) sebagai JSON dengan kunci nama flag, jadi hasilnya bisa
+// langsung dipakai lagi sebagai -config.
+func printEffectiveConfig(w io.Writer) error {
+	out := map[string]interface{}{}
+	flag.VisitAll(func(f *flag.Flag) {
+		switch v := f.Value.(type) {
+		case repeatedFlag:
+			out[f.Name] = v.items()
+		case flag.Getter:
+			out[f.Name] = v.Get()
+		default:
+			out[f.Name] = v.String()
+		}
+	})
+	delete(out, "config")
+	delete(out, "printConfig")
+	if len(serviceDefs) > 0 {
+		svcs := map[string]interface{}{}
+		for k, def := range serviceDefs {
+			svcs[k] = map[string]string{"label": def.Label, "time": fmt.Sprintf("%02d:%02d", def.Hour, def.Minute)}
+		}
+		out["services"] = svcs
+	}
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", b)
+	return err
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// loadScheduleAssign membaca output sebelumnya (.json atau .xlsx) kembali
+// menjadi Assignment, untuk -diff.
+func loadScheduleAssign(path string, maps []RoleMap, loc *time.Location) (Assignment, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return loadAssignJSON(path, loc)
+	case ".xlsx":
+		return loadAssignXLSX(path, maps, loc)
+	}
+	return nil, errors.New("ekstensi harus .xlsx atau .json")
+}
+
+func loadAssignJSON(path string, loc *time.Location) (Assignment, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var days []jsonDay
+	if err := json.Unmarshal(b, &days); err != nil {
+		return nil, err
+	}
+	res := Assignment{}
+	for _, day := range days {
+		d, err := time.ParseInLocation("2006-01-02", day.Date, loc)
+		if err != nil {
+			return nil, err
+		}
+		res[d] = day.Services
+	}
+	return res, nil
+}
+
+// loadAssignXLSX: baris tiap role/ibadah dicari dengan roleRowIndex (sama
+// seperti writer), kolom tanggal dari header yang sudah terisi.
+func loadAssignXLSX(path string, maps []RoleMap, loc *time.Location) (Assignment, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sheet, err := requireSheet(f, *sheetFlag)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	ix := newRoleRowIndex(rows)
+	colDate := headerDateColumns(rows, loc)
+	if len(colDate) == 0 {
+		return nil, errors.New("tidak ada header tanggal yang dikenali")
+	}
+
+	res := Assignment{}
+	for _, svc := range scheduler.ServiceKeys(maps, configuredServiceKeys()) {
+		for _, m := range maps {
+			if !scheduler.InService(m, svc) {
+				continue
+			}
+			row := ix.row(m.Role, svc)
+			if row < 1 {
+				continue
+			}
+			for c, d := range colDate {
+				names := []string{}
+				if r := rows[row-1]; c < len(r) {
+					for _, n := range strings.Split(r[c], "\n") {
+						if n = strings.TrimSpace(n); n != "" && !isBackupLine(n) {
+							names = append(names, n)
+						}
+					}
+				}
+				if res[d] == nil {
+					res[d] = map[string]map[string][]string{}
+				}
+				if res[d][svc] == nil {
+					res[d][svc] = map[string][]string{}
+				}
+				res[d][svc][m.Role] = names
+			}
+		}
+	}
+	return res, nil
+}
+
+// diffAssign: perubahan per sel "tanggal ibadah role: lama -> baru" untuk
+// tanggal di dates. Hanya role yang ada di kedua jadwal yang dibandingkan;
+// urutan nama dalam satu sel diabaikan. ok=false bila old tidak punya satu
+// pun tanggal tersebut.
+func diffAssign(old, cur Assignment, dates []time.Time) (changes []string, ok bool) {
+	for _, d := range dates {
+		if old[d] == nil {
+			continue
+		}
+		ok = true
+		for _, svc := range sortedServices(cur[d]) {
+			for _, role := range sortedRoles(cur[d][svc]) {
+				prev, found := old[d][svc][role]
+				if !found {
+					continue
+				}
+				now := cur[d][svc][role]
+				if sameNames(prev, now) {
+					continue
+				}
+				changes = append(changes, fmt.Sprintf("%s %s %s: %s -> %s", d.Format("02-01-2006"),
+					serviceName(svc, "."), role, nameList(prev), nameList(now)))
+			}
+		}
+	}
+	return changes, ok
+}
+
+func sameNames(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	x := append([]string(nil), a...)
+	y := append([]string(nil), b...)
+	sort.Strings(x)
+	sort.Strings(y)
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func nameList(names []string) string {
+	if len(names) == 0 {
+		return tr("(kosong)")
+	}
+	return strings.Join(names, ", ")
+}
+
+// printDiff mencetak hasil diffAssign terhadap file -diff.
+func printDiff(old, cur Assignment, dates []time.Time, path string) {
+	changes, ok := diffAssign(old, cur, dates)
+	switch {
+	case !ok:
+		infof("WARN: -diff %s tidak memuat tanggal %s\n", path, monthNameID(int(dates[0].Month())))
+	case len(changes) == 0:
+		infof("Tidak ada perubahan dibanding %s\n", path)
+	default:
+		infof("Perubahan dibanding %s (%d sel):\n", path, len(changes))
+		for _, c := range changes {
+			infof("  %s\n", c)
+		}
+	}
+}
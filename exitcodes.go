@@ -0,0 +1,59 @@
+package main
+
+import "errors"
+
+// ==================== Exit code ====================
+// Kode keluar per kategori error, supaya skrip bisa bereaksi berbeda.
+// Error tanpa kategori (mis. gagal membaca file lain) keluar dengan 1.
+
+const (
+	exitFailure      = 1 // error lain
+	exitUsage        = 2 // argumen/flag salah (sama dengan kode flag.Parse)
+	exitNoMaster     = 3 // Master.xlsx tidak ditemukan
+	exitBadMaster    = 4 // Master ada tetapi tidak valid (sheet/kolom/isi)
+	exitInfeasible   = 5 // -strictComposition menyisakan slot wajib kosong, atau audit -noCrossService gagal
+	exitWriteFailure = 6 // gagal menulis file output
+)
+
+// exitCodesHelp ditambahkan di akhir -h.
+const exitCodesHelp = `
+Exit code:
+  0  sukses
+  1  error lain
+  2  argumen/flag salah
+  3  Master.xlsx tidak ditemukan
+  4  Master.xlsx tidak valid
+  5  jadwal tidak bisa dipenuhi (-strictComposition menyisakan slot kosong, audit -noCrossService gagal)
+  6  gagal menulis file output
+`
+
+// codedError: error dengan kode keluar; main memetakannya lewat exitCode.
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+// withCode memberi err kategori exit code; nil tetap nil, kategori yang
+// sudah ada tidak ditimpa.
+func withCode(code int, err error) error {
+	var ce *codedError
+	if err == nil || errors.As(err, &ce) {
+		return err
+	}
+	return &codedError{code: code, err: err}
+}
+
+func usageErr(err error) error { return withCode(exitUsage, err) }
+func writeErr(err error) error { return withCode(exitWriteFailure, err) }
+
+// exitCode: kode keluar untuk err (exitFailure bila tanpa kategori).
+func exitCode(err error) int {
+	var ce *codedError
+	if errors.As(err, &ce) {
+		return ce.code
+	}
+	return exitFailure
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"strconv"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// writeCSV menulis jadwal format panjang: satu baris per orang per role.
+// Role urut MappingRole; role tanpa petugas tetap ditulis dengan nama kosong
+// agar celah terlihat.
+// plan != nil (-slotCounts): tambah kolom Terisi & Diminta per baris.
+// Dengan cadangan (-substitutes): kolom Cadangan, baris cadangan bernilai "ya".
+func writeCSV(sched scheduler.Schedule, plan scheduler.SlotPlan, outPath string) error {
+	f, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := csv.NewWriter(f)
+	header := []string{tr("Tanggal"), tr("Ibadah"), "Role", tr("Nama")}
+	if plan != nil {
+		header = append(header, tr("Terisi"), tr("Diminta"))
+	}
+	backupCol := sched.HasBackups()
+	if backupCol {
+		header = append(header, tr("Cadangan"))
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, day := range sched.Days {
+		d := day.Date
+		date := d.Format("02/01/2006")
+		for _, sv := range day.Services {
+			svc := sv.Service
+			for _, r := range sv.Roles {
+				role, names := r.Role, r.Names
+				var counts []string
+				if n, ok := plan[d][svc][role]; ok {
+					counts = []string{strconv.Itoa(len(names)), strconv.Itoa(n)}
+				} else if plan != nil {
+					counts = []string{strconv.Itoa(len(names)), ""}
+				}
+				if len(names) == 0 {
+					names = []string{""}
+				}
+				for _, n := range names {
+					rec := append([]string{date, serviceName(svc, "."), role, n}, counts...)
+					if backupCol {
+						rec = append(rec, "")
+					}
+					if err := w.Write(rec); err != nil {
+						return err
+					}
+				}
+				for _, n := range r.Backups {
+					rec := append([]string{date, serviceName(svc, "."), role, n}, make([]string, len(counts))...)
+					if err := w.Write(append(rec, tr("ya"))); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return f.Close()
+}
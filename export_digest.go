@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// writeDigest menulis ringkasan per keluarga role (Lektor, Prokantor, ...)
+// untuk koordinator tim: satu bagian per role dasar (urutan MappingRole),
+// satu baris per tanggal/ibadah. Tanggal tanpa petugas ditulis "(kosong)"
+// agar celah mudah dicek.
+func writeDigest(sched scheduler.Schedule, maps []RoleMap, outPath string) error {
+	var families []string
+	label := map[string]string{}
+	for _, m := range maps {
+		key := scheduler.BaseRole(m.Role)
+		if _, ok := label[key]; !ok {
+			label[key] = roleLabel(m.Role)
+			families = append(families, key)
+		}
+	}
+
+	var b strings.Builder
+	for i, fam := range families {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "== %s ==\n", label[fam])
+		for _, day := range sched.Days {
+			d := day.Date
+			for _, sv := range day.Services {
+				var names, backups []string
+				seen := false
+				for _, r := range sv.Roles {
+					if scheduler.BaseRole(r.Role) == fam {
+						seen = true
+						names = append(names, r.Names...)
+						backups = append(backups, r.Backups...)
+					}
+				}
+				if !seen {
+					continue // role ini tidak ada di ibadah tersebut
+				}
+				list := strings.Join(names, ", ")
+				if len(names) == 0 {
+					list = tr("(kosong)")
+				}
+				fmt.Fprintf(&b, "%s, %02d %s %d (%s): %s%s\n", dayNameID(d.Weekday()), d.Day(),
+					monthNameID(int(d.Month())), d.Year(), serviceName(sv.Service, "."), list, backupNote(backups))
+			}
+		}
+	}
+	return os.WriteFile(outPath, []byte(b.String()), 0o644)
+}
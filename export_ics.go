@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// Durasi event per ibadah di kalender.
+const icsEventDuration = 90 * time.Minute
+
+// writeICS menulis VCALENDAR dengan satu VEVENT per ibadah per tanggal.
+// Jam mulai dari kunci "services" di -config, atau dari kunci service
+// ("07" -> 07:00), pada lokasi tanggal (zona -tz). UID stabil dari tanggal+service sehingga impor ulang
+// memperbarui event yang sama, bukan menduplikasi.
+func writeICS(sched scheduler.Schedule, outPath string) error {
+	var b strings.Builder
+	stamp := time.Now().UTC().Format("20060102T150405Z")
+
+	icsLine(&b, "BEGIN:VCALENDAR")
+	icsLine(&b, "VERSION:2.0")
+	icsLine(&b, "PRODID:-//JadwalPetugas//jadwal-petugas-cli//ID")
+	icsLine(&b, "CALSCALE:GREGORIAN")
+	icsLine(&b, "METHOD:PUBLISH")
+	for _, day := range sched.Days {
+		d := day.Date
+		for _, sv := range day.Services {
+			svc := sv.Service
+			if len(sv.Roles) == 0 {
+				continue
+			}
+			h, m := serviceClock(svc)
+			start := time.Date(d.Year(), d.Month(), d.Day(), h, m, 0, 0, d.Location())
+			end := start.Add(icsEventDuration)
+
+			var parts []string
+			for _, r := range sv.Roles {
+				if len(r.Names) > 0 {
+					parts = append(parts, r.Role+": "+strings.Join(r.Names, ", ")+backupNote(r.Backups))
+				}
+			}
+			summary := fmt.Sprintf("%s %s - %s", tr("Ibadah"), serviceName(svc, "."), strings.Join(parts, "; "))
+
+			icsLine(&b, "BEGIN:VEVENT")
+			icsLine(&b, fmt.Sprintf("UID:%s-%s@jadwal-petugas", d.Format("20060102"), svc))
+			icsLine(&b, "DTSTAMP:"+stamp)
+			icsLine(&b, "DTSTART:"+start.UTC().Format("20060102T150405Z"))
+			icsLine(&b, "DTEND:"+end.UTC().Format("20060102T150405Z"))
+			icsLine(&b, "SUMMARY:"+icsEscape(summary))
+			icsLine(&b, "DESCRIPTION:"+icsEscape(strings.Join(parts, "\n")))
+			icsLine(&b, "END:VEVENT")
+		}
+	}
+	icsLine(&b, "END:VCALENDAR")
+	return os.WriteFile(outPath, []byte(b.String()), 0o644)
+}
+
+// sortedRoles mengembalikan nama role terurut agar output stabil.
+func sortedRoles(roles map[string][]string) []string {
+	keys := make([]string, 0, len(roles))
+	for k := range roles {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func icsEscape(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\n", `\n`)
+	return r.Replace(s)
+}
+
+// icsLine menulis satu content line dengan CRLF dan folding 75 oktet (RFC 5545).
+func icsLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		// jangan potong di tengah karakter UTF-8
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // baris lanjutan diawali spasi
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
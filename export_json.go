@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// jsonDay adalah satu tanggal pada output JSON: "date", "day", lalu satu
+// objek per kunci ibadah ("07", "10", ...) berisi role -> nama, dan
+// "cadangan" (service -> role -> nama) bila -substitutes dipakai.
+type jsonDay struct {
+	Date     string                         // ISO yyyy-mm-dd
+	Day      string                         // nama hari (ID)
+	Services map[string]map[string][]string // service -> role -> nama
+	Backups  map[string]map[string][]string // -substitutes; nil = tidak ditulis
+}
+
+// MarshalJSON menulis "date" & "day" lebih dulu, lalu service terurut,
+// lalu "cadangan".
+func (j jsonDay) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(`{"date":`)
+	b, _ := json.Marshal(j.Date)
+	buf.Write(b)
+	buf.WriteString(`,"day":`)
+	b, _ = json.Marshal(j.Day)
+	buf.Write(b)
+	for _, svc := range sortedServices(j.Services) {
+		k, _ := json.Marshal(svc)
+		v, err := json.Marshal(j.Services[svc])
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteByte(',')
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	if j.Backups != nil {
+		v, err := json.Marshal(j.Backups)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString(`,"cadangan":`)
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func (j *jsonDay) UnmarshalJSON(b []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	j.Services = map[string]map[string][]string{}
+	for k, v := range raw {
+		var err error
+		switch k {
+		case "date":
+			err = json.Unmarshal(v, &j.Date)
+		case "day":
+			err = json.Unmarshal(v, &j.Day)
+		case "cadangan":
+			err = json.Unmarshal(v, &j.Backups)
+		default:
+			var roles map[string][]string
+			err = json.Unmarshal(v, &roles)
+			j.Services[k] = roles
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if j.Date == "" {
+		return errors.New("entri tanpa date")
+	}
+	return nil
+}
+
+// writeJSON menulis Assignment sebagai array tanggal (urut sesuai dates).
+// backups (-substitutes, boleh nil) ditulis sebagai "cadangan" per tanggal.
+func writeJSON(assign, backups Assignment, dates []time.Time, outPath string) error {
+	days := make([]jsonDay, 0, len(dates))
+	for _, d := range dates {
+		svcs := map[string]map[string][]string{}
+		for svc, roles := range assign[d] {
+			svcs[svc] = jsonRoles(roles)
+		}
+		days = append(days, jsonDay{
+			Date:     d.Format("2006-01-02"),
+			Day:      dayNames["id"][d.Weekday()], // selalu ID, tidak ikut -lang
+			Services: svcs,
+			Backups:  backups[d],
+		})
+	}
+	b, err := json.MarshalIndent(days, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, append(b, '\n'), 0o644)
+}
+
+// jsonRoles memastikan role kosong tetap ditulis sebagai [] (bukan null).
+func jsonRoles(in map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(in))
+	for role, names := range in {
+		if names == nil {
+			names = []string{}
+		}
+		out[role] = names
+	}
+	return out
+}
+
+// writeExplain menulis jejak keputusan generate sebagai array JSON, satu
+// objek per orang per slot, urut sesuai jalannya pengisian.
+func writeExplain(trace *scheduler.Trace, outPath string) error {
+	entries := trace.Entries
+	if entries == nil {
+		entries = []scheduler.TraceEntry{}
+	}
+	b, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outPath, append(b, '\n'), 0o644)
+}
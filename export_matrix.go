@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// eligibilityMatrix menyusun tabel petugas x kolom sumber MappingRole:
+// "X" bila eligible, plus kolom jumlah per orang dan baris jumlah per role.
+// Kolom yang hanya dipakai Majelis Pendamping hanya menghitung Penatua.
+func eligibilityMatrix(people []Person, maps []RoleMap) [][]string {
+	var cols []string // SourceColumn apa adanya, urutan pertama muncul
+	mpOnly := map[string]bool{}
+	seen := map[string]bool{}
+	for _, m := range maps {
+		k := scheduler.NormKey(m.SourceColumn)
+		if !seen[k] {
+			seen[k] = true
+			cols = append(cols, m.SourceColumn)
+			mpOnly[k] = true
+		}
+		if !scheduler.IsMajelisPendamping(m.Role) {
+			mpOnly[k] = false
+		}
+	}
+
+	eligible := make([]map[string]bool, len(cols))
+	for i, c := range cols {
+		eligible[i] = map[string]bool{}
+		for _, n := range scheduler.FilterCandidates(people, c, mpOnly[scheduler.NormKey(c)], time.Time{}) {
+			eligible[i][n] = true
+		}
+	}
+
+	header := append([]string{tr("Nama")}, cols...)
+	rows := [][]string{append(header, tr("Jumlah"))}
+	colCount := make([]int, len(cols))
+	total := 0
+	for _, p := range people {
+		row := []string{p.Name}
+		n := 0
+		for i := range cols {
+			mark := ""
+			if eligible[i][p.Name] {
+				mark = "X"
+				n++
+				colCount[i]++
+			}
+			row = append(row, mark)
+		}
+		total += n
+		rows = append(rows, append(row, strconv.Itoa(n)))
+	}
+	last := []string{tr("Jumlah")}
+	for _, c := range colCount {
+		last = append(last, strconv.Itoa(c))
+	}
+	return append(rows, append(last, strconv.Itoa(total)))
+}
+
+// writeMatrix menulis matriks ke .csv, atau .xlsx untuk ekstensi lain.
+func writeMatrix(rows [][]string, outPath string) error {
+	if strings.EqualFold(filepath.Ext(outPath), ".csv") {
+		f, err := os.Create(outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w := csv.NewWriter(f)
+		if err := w.WriteAll(rows); err != nil {
+			return err
+		}
+		return f.Close()
+	}
+
+	f := excelize.NewFile()
+	defer f.Close()
+	const sheet = "Matriks"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+	for r, row := range rows {
+		vals := make([]interface{}, len(row))
+		for i, v := range row {
+			vals[i] = v
+			// kolom/baris jumlah sebagai angka agar bisa diolah di Excel
+			if n, err := strconv.Atoi(v); err == nil && r > 0 {
+				vals[i] = n
+			}
+		}
+		if err := f.SetSheetRow(sheet, cell(1, r+1), &vals); err != nil {
+			return err
+		}
+	}
+	if err := f.SetPanes(sheet, &excelize.Panes{Freeze: true, XSplit: 1, YSplit: 1, TopLeftCell: "B2", ActivePane: "bottomRight"}); err != nil {
+		return err
+	}
+	return f.SaveAs(outPath)
+}
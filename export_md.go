@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// writeMarkdown menulis satu tabel per tanggal untuk wiki: kolom Role lalu
+// satu kolom per ibadah, baris mengikuti urutan MappingRole.
+// plan != nil (-slotCounts): sel diberi anotasi "(terisi/diminta)".
+func writeMarkdown(sched scheduler.Schedule, plan scheduler.SlotPlan, maps []RoleMap, outPath string) error {
+	roles := mappingRoles(maps)
+
+	var b strings.Builder
+	if len(sched.Days) > 0 {
+		first := sched.Days[0].Date
+		fmt.Fprintf(&b, "# %s %s %d\n", tr("Jadwal Petugas"), monthNameID(int(first.Month())), first.Year())
+	}
+	for _, day := range sched.Days {
+		d, services := day.Date, day.Services
+		fmt.Fprintf(&b, "\n## %s, %02d %s %d\n\n", dayNameID(d.Weekday()), d.Day(), monthNameID(int(d.Month())), d.Year())
+		b.WriteString("| Role |")
+		for _, sv := range services {
+			fmt.Fprintf(&b, " %s |", mdEscape(serviceName(sv.Service, ":")))
+		}
+		b.WriteString("\n|---|")
+		b.WriteString(strings.Repeat("---|", len(services)))
+		b.WriteString("\n")
+		for _, role := range roles {
+			fmt.Fprintf(&b, "| %s |", mdEscape(role))
+			for i := range services {
+				r := services[i].Role(role)
+				cell := strings.Join(r.Names, ", ") + slotCount(plan, d, services[i].Service, role, len(r.Names)) + backupNote(r.Backups)
+				fmt.Fprintf(&b, " %s |", mdEscape(strings.TrimSpace(cell)))
+			}
+			b.WriteString("\n")
+		}
+	}
+	return os.WriteFile(outPath, []byte(b.String()), 0o644)
+}
+
+// mdEscape: pipe memutus sel tabel Markdown.
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
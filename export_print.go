@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// printSchedule (-print) menulis jadwal sebagai tabel teks ke w: satu blok
+// per tanggal, baris role urut MappingRole, satu kolom per ibadah.
+// "-" = role tidak diminta di ibadah itu (menurut plan); counts (-slotCounts)
+// memberi anotasi "(terisi/diminta)".
+func printSchedule(w io.Writer, sched scheduler.Schedule, plan scheduler.SlotPlan, counts bool, maps []RoleMap) error {
+	roles := mappingRoles(maps)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, day := range sched.Days {
+		d, services := day.Date, day.Services
+		if i > 0 {
+			fmt.Fprintln(tw)
+		}
+		fmt.Fprintf(tw, "%s, %02d %s %d\n", dayNameID(d.Weekday()), d.Day(), monthNameID(int(d.Month())), d.Year())
+		head := []string{"Role"}
+		for _, sv := range services {
+			head = append(head, serviceName(sv.Service, "."))
+		}
+		fmt.Fprintln(tw, strings.Join(head, "\t"))
+		for _, role := range roles {
+			cells := []string{role}
+			used := false
+			for i := range services {
+				svc := services[i].Service
+				r := services[i].Role(role)
+				names := r.Names
+				count := ""
+				if counts {
+					count = slotCount(plan, d, svc, role, len(names))
+				}
+				switch {
+				case len(names) == 0 && plan[d][svc][role] == 0:
+					cells = append(cells, "-")
+				case len(names) == 0:
+					cells = append(cells, tr("(kosong)")+count+backupNote(r.Backups))
+					used = true
+				default:
+					cells = append(cells, strings.Join(names, ", ")+count+backupNote(r.Backups))
+					used = true
+				}
+			}
+			if used {
+				fmt.Fprintln(tw, strings.Join(cells, "\t"))
+			}
+		}
+	}
+	return tw.Flush()
+}
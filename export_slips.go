@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// slipEntry: satu tugas seseorang (kebalikan Assignment).
+type slipEntry struct {
+	Date    time.Time
+	Service string
+	Role    string
+	Backup  bool // cadangan (-substitutes), bukan petugas
+}
+
+// personSlips membalik Assignment menjadi nama -> daftar tugas, urut tanggal,
+// jam ibadah, lalu urutan role seperti di jadwal (MappingRole).
+func personSlips(sched scheduler.Schedule) map[string][]slipEntry {
+	res := map[string][]slipEntry{}
+	for _, day := range sched.Days {
+		for _, sv := range day.Services {
+			for _, r := range sv.Roles {
+				for _, n := range r.Names {
+					res[n] = append(res[n], slipEntry{Date: day.Date, Service: sv.Service, Role: r.Role})
+				}
+				for _, n := range r.Backups {
+					res[n] = append(res[n], slipEntry{Date: day.Date, Service: sv.Service, Role: r.Role, Backup: true})
+				}
+			}
+		}
+	}
+	return res
+}
+
+// writeSlips menulis slip per petugas: satu blok per nama (urut abjad),
+// berisi tanggal, ibadah, dan role yang ia pegang. Tugas cadangan ditandai
+// "(Cadangan)" dan tidak ikut dihitung di jumlah tugas.
+func writeSlips(sched scheduler.Schedule, outPath string) error {
+	slips := personSlips(sched)
+	names := make([]string, 0, len(slips))
+	for n := range slips {
+		names = append(names, n)
+	}
+	sort.Slice(names, func(i, j int) bool { return strings.ToLower(names[i]) < strings.ToLower(names[j]) })
+
+	var b strings.Builder
+	for i, n := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		duties := 0
+		for _, e := range slips[n] {
+			if !e.Backup {
+				duties++
+			}
+		}
+		fmt.Fprintf(&b, "%s (%d)\n", n, duties)
+		for _, e := range slips[n] {
+			role := e.Role
+			if e.Backup {
+				role += " (" + tr("Cadangan") + ")"
+			}
+			fmt.Fprintf(&b, "- %s, %02d %s %d, %s %s: %s\n", dayNameID(e.Date.Weekday()), e.Date.Day(),
+				monthNameID(int(e.Date.Month())), e.Date.Year(), tr("Ibadah"), serviceName(e.Service, "."), role)
+		}
+	}
+	return os.WriteFile(outPath, []byte(b.String()), 0o644)
+}
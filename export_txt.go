@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// writeTXT menulis roster teks polos untuk dibagikan (mis. WhatsApp):
+// satu judul per tanggal, lalu "Role: nama1, nama2" per ibadah (urut MappingRole),
+// plus " (Cadangan: ...)" bila ada cadangan (-substitutes).
+// Role tanpa petugas dilewati agar ringkas, kecuali dengan plan (-slotCounts):
+// semua role yang diminta ditulis dengan anotasi "(terisi/diminta)".
+func writeTXT(sched scheduler.Schedule, plan scheduler.SlotPlan, outPath string) error {
+	var b strings.Builder
+	for i, day := range sched.Days {
+		d := day.Date
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s, %02d %s %d\n", dayNameID(d.Weekday()), d.Day(), monthNameID(int(d.Month())), d.Year())
+		for _, sv := range day.Services {
+			svc := sv.Service
+			var lines []string
+			for _, r := range sv.Roles {
+				role, names := r.Role, r.Names
+				count := slotCount(plan, d, svc, role, len(names))
+				switch {
+				case len(names) > 0:
+					lines = append(lines, fmt.Sprintf("- %s%s: %s%s", role, count, strings.Join(names, ", "), backupNote(r.Backups)))
+				case count != "":
+					lines = append(lines, fmt.Sprintf("- %s%s: %s%s", role, count, tr("(kosong)"), backupNote(r.Backups)))
+				}
+			}
+			if len(lines) == 0 {
+				continue
+			}
+			fmt.Fprintf(&b, "%s %s\n", tr("Ibadah"), serviceName(svc, "."))
+			for _, l := range lines {
+				b.WriteString(l + "\n")
+			}
+		}
+	}
+	return os.WriteFile(outPath, []byte(b.String()), 0o644)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// slotGap: satu role yang terisi kurang dari yang diminta.
+type slotGap struct {
+	Date            time.Time
+	Service, Role   string
+	Requested, Fill int
+}
+
+// findGaps membandingkan rencana slot dengan hasil akhir (setelah rebalance).
+func findGaps(plan scheduler.SlotPlan, assign Assignment, dates []time.Time) []slotGap {
+	var gaps []slotGap
+	for _, d := range dates {
+		svcs := map[string]map[string][]string{}
+		for svc := range plan[d] {
+			svcs[svc] = nil
+		}
+		for _, svc := range sortedServices(svcs) {
+			req := plan[d][svc]
+			roles := make(map[string][]string, len(req))
+			for role := range req {
+				roles[role] = nil
+			}
+			for _, role := range sortedRoles(roles) {
+				n := req[role]
+				got := len(assign[d][svc][role])
+				if got < n {
+					gaps = append(gaps, slotGap{Date: d, Service: svc, Role: role, Requested: n, Fill: got})
+				}
+			}
+		}
+	}
+	return gaps
+}
+
+// slotCount: anotasi " (terisi/diminta)" untuk ekspor -slotCounts; kosong
+// bila plan nil (opsi mati), role tidak ada di rencana slot, atau baris
+// yang tidak diminta sama sekali (mis. Kolektan 4 pada pola 3 orang).
+func slotCount(plan scheduler.SlotPlan, d time.Time, svc, role string, got int) string {
+	n, ok := plan[d][svc][role]
+	if !ok || (n == 0 && got == 0) {
+		return ""
+	}
+	return fmt.Sprintf(" (%d/%d)", got, n)
+}
+
+// printGaps selalu dicetak (bukan hanya -v): slot kosong penting secara operasional.
+func printGaps(gaps []slotGap) {
+	if len(gaps) == 0 {
+		return
+	}
+	missing := 0
+	for _, g := range gaps {
+		missing += g.Requested - g.Fill
+	}
+	infof(paint(ansiRed, "Kekurangan petugas: %d slot kosong di %d role\n"), missing, len(gaps))
+	for _, g := range gaps {
+		infof(paint(ansiRed, "  %s %s %-20s kurang %d (terisi %d/%d)\n"),
+			g.Date.Format("02-01-2006"), serviceName(g.Service, "."), g.Role, g.Requested-g.Fill, g.Fill, g.Requested)
+	}
+}
+
+// printStrictGaps merangkum slot Kolektan/P. Jemaat yang kosong pada run
+// -strictComposition (tanpa strict, relax-any biasanya mengisinya), total dan
+// per tanggal. Lebih dari warnAt slot -> saran melonggarkan pola.
+// Mengembalikan jumlah slot kosong itu.
+func printStrictGaps(gaps []slotGap, warnAt int) int {
+	total := 0
+	perDate := map[time.Time]int{}
+	var dates []time.Time
+	for _, g := range gaps {
+		if b := scheduler.BaseRole(g.Role); b != "kolektan" && b != "pjemaat" {
+			continue
+		}
+		if perDate[g.Date] == 0 {
+			dates = append(dates, g.Date)
+		}
+		perDate[g.Date] += g.Requested - g.Fill
+		total += g.Requested - g.Fill
+	}
+	if total == 0 {
+		return 0
+	}
+	infof(paint(ansiRed, "Strict komposisi: %d slot Kolektan/P. Jemaat dibiarkan kosong\n"), total)
+	for _, d := range dates {
+		infof(paint(ansiRed, "  %s: %d slot\n"), d.Format("02-01-2006"), perDate[d])
+	}
+	if warnAt >= 0 && total > warnAt {
+		infof(paint(ansiYellow, "WARN: %d slot kosong (> %d) karena -strictComposition; longgarkan -kolektanPattern/-pjemaatPattern atau jalankan tanpa -strictComposition\n"),
+			total, warnAt)
+	}
+	return total
+}
@@ -2,7 +2,10 @@ module jadwal-petugas-cli
 
 go 1.21
 
-require github.com/xuri/excelize/v2 v2.8.1
+require (
+	github.com/xuri/excelize/v2 v2.8.1
+	gopkg.in/yaml.v3 v3.0.1
+)
 
 require (
 	github.com/mohae/deepcopy v0.0.0-20170929034955-c48cc78d4826 // indirect
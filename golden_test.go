@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+var updateGolden = flag.Bool("update", false, "tulis ulang file golden di testdata/golden")
+
+// Hasil GenerateSchedule untuk testdata/Master.xlsx (seed tetap, -stableOrder)
+// dibandingkan dengan testdata/golden/<nama>.json. Perubahan perilaku yang
+// disengaja: go test -run TestGenerateGolden -update, lalu periksa diff-nya.
+func TestGenerateGolden(t *testing.T) {
+	saved := logLvl
+	logLvl = levelQuiet
+	defer func() { logLvl = saved }()
+
+	people, maps, err := loadMaster(filepath.Join("testdata", "Master.xlsx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dates []time.Time
+	for _, day := range []int{3, 10, 17, 24, 31} {
+		dates = append(dates, time.Date(2025, 8, day, 0, 0, 0, 0, time.UTC))
+	}
+
+	cases := []struct {
+		name string
+		set  func(*scheduler.Config)
+	}{
+		{"normal", func(*scheduler.Config) {}},
+		{"strict", func(c *scheduler.Config) {
+			c.Kolektan = scheduler.Quota{Penatua: 1, Jemaat: 2} // hanya satu Jemaat bertanda Kolektan
+			c.StrictComposition = true
+		}},
+		{"norelaxb2b", func(c *scheduler.Config) {
+			c.CooldownWeeks = 2
+			c.NoRelaxB2B = true
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := scheduler.NewConfig(dates)
+			cfg.Seed = 7
+			cfg.StableOrder = true
+			tc.set(&cfg)
+			assign, err := scheduler.GenerateSchedule(cfg, people, maps)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := filepath.Join(t.TempDir(), tc.name+".json")
+			if err := writeJSON(assign, nil, dates, out); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "golden", tc.name+".json")
+			if *updateGolden {
+				if err := os.MkdirAll(filepath.Dir(golden), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v (jalankan dengan -update untuk membuat golden)", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("hasil berbeda dari %s; jalankan dengan -update bila perubahan disengaja\ndapat:\n%s", golden, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// historyEntry adalah satu penugasan yang tersimpan lintas bulan.
+type historyEntry struct {
+	Name    string `json:"name"`
+	Date    string `json:"date"` // yyyy-mm-dd
+	Service string `json:"service"`
+	Role    string `json:"role"`
+}
+
+type historyStore struct {
+	Entries []historyEntry `json:"entries"`
+}
+
+// loadHistory membaca file riwayat; file yang belum ada = riwayat kosong.
+func loadHistory(path string) (*historyStore, error) {
+	b, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &historyStore{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var h historyStore
+	if err := json.Unmarshal(b, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// served mengembalikan nama -> tanggal bertugas, untuk seed cooldown.
+func (h *historyStore) served(loc *time.Location) (map[string][]time.Time, error) {
+	res := map[string][]time.Time{}
+	for _, e := range h.Entries {
+		d, err := time.ParseInLocation("2006-01-02", e.Date, loc)
+		if err != nil {
+			return nil, err
+		}
+		addServed(res, e.Name, d)
+	}
+	return res, nil
+}
+
+// record mengganti entri pada tanggal-tanggal yang baru digenerate
+// (agar generate ulang tidak menggandakan) lalu menambahkan hasil baru.
+// only (role dasar, dari -roles) membatasi entri yang diganti; nil = semua.
+func (h *historyStore) record(assign Assignment, dates []time.Time, only map[string]bool) {
+	regen := map[string]bool{}
+	for _, d := range dates {
+		regen[d.Format("2006-01-02")] = true
+	}
+	kept := h.Entries[:0]
+	for _, e := range h.Entries {
+		if !regen[e.Date] || (only != nil && !only[scheduler.BaseRole(e.Role)]) {
+			kept = append(kept, e)
+		}
+	}
+	h.Entries = kept
+	for _, d := range dates {
+		for svc, roles := range assign[d] {
+			for role, names := range roles {
+				for _, n := range names {
+					h.Entries = append(h.Entries, historyEntry{Name: n, Date: d.Format("2006-01-02"), Service: svc, Role: role})
+				}
+			}
+		}
+	}
+	sort.Slice(h.Entries, func(i, j int) bool {
+		a, b := h.Entries[i], h.Entries[j]
+		if a.Date != b.Date {
+			return a.Date < b.Date
+		}
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		if a.Role != b.Role {
+			return a.Role < b.Role
+		}
+		return a.Name < b.Name
+	})
+}
+
+// save menulis riwayat lewat file sementara + rename agar tidak korup.
+func (h *historyStore) save(path string) error {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// mergeServed menggabungkan dua peta nama -> tanggal bertugas.
+func mergeServed(a, b map[string][]time.Time) map[string][]time.Time {
+	if a == nil {
+		a = map[string][]time.Time{}
+	}
+	for name, ds := range b {
+		for _, d := range ds {
+			addServed(a, name, d)
+		}
+	}
+	return a
+}
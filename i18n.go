@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// ==================== Bahasa output (-lang) ====================
+
+var monthNames = map[string][]string{
+	"id": {"", "Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"},
+	"en": {"", "January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+}
+
+// dayNames diindeks dengan time.Weekday (Minggu = 0).
+var dayNames = map[string][]string{
+	"id": {"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"},
+	"en": {"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+}
+
+// labels: teks ringkasan CLI/output berbahasa Indonesia -> terjemahan.
+// Teks yang tidak ada di tabel dicetak apa adanya.
+var labels = map[string]map[string]string{
+	"en": {
+		"SUKSES:":                                "SUCCESS:",
+		"Rekap penugasan per petugas:":           "Assignment summary per person:",
+		"Nama":                                   "Name",
+		"Tanggal":                                "Date",
+		"Terisi":                                 "Filled",
+		"Diminta":                                "Requested",
+		"Ibadah":                                 "Service",
+		"Jadwal Petugas":                         "Service Roster",
+		"Jumlah":                                 "Total",
+		"WARN: %s bertugas %d kali (batas %d)\n": "WARN: %s serves %d times (limit %d)\n",
+		"Selisih":                                "vs mean",
+		"(kosong)":                               "(empty)",
+		"Cadangan":                               "Backup",
+		"ya":                                     "yes",
+		"Keadilan: rata-rata %.2f tugas/orang, simpangan baku %.2f (%d eligible, %d tanpa tugas)\n": "Fairness: mean %.2f assignments/person, std dev %.2f (%d eligible, %d unassigned)\n",
+	},
+}
+
+func outLang() string {
+	return strings.ToLower(strings.TrimSpace(*langFlag))
+}
+
+func tr(s string) string {
+	if t, ok := labels[outLang()][s]; ok {
+		return t
+	}
+	return s
+}
+
+func monthNameID(m int) string {
+	names, ok := monthNames[outLang()]
+	if !ok {
+		names = monthNames["id"]
+	}
+	if m >= 1 && m <= 12 {
+		return names[m]
+	}
+	return "?"
+}
+
+// shortName: singkatan 3 huruf nama hari/bulan ({DayShort}, {MonShort}), mis.
+// "Minggu" -> "Min", "Agustus" -> "Agu", "September" -> "Sep".
+func shortName(name string) string {
+	r := []rune(name)
+	if len(r) > 3 {
+		r = r[:3]
+	}
+	return string(r)
+}
+
+// New: day name (sesuai -lang, default Indonesia)
+func dayNameID(wd time.Weekday) string {
+	names, ok := dayNames[outLang()]
+	if !ok {
+		names = dayNames["id"]
+	}
+	return names[wd]
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// ==================== Penulisan paralel per bulan (-jobs) ====================
+
+// monthRun: hasil generate satu bulan, ditulis di tahap kedua.
+type monthRun struct {
+	dates   []time.Time
+	assign  Assignment
+	backups Assignment // -substitutes, nil = tanpa cadangan
+	plan    scheduler.SlotPlan
+	trace   *scheduler.Trace
+}
+
+// monthWrite: hasil menulis satu bulan (file yang sudah ditulis, meski gagal di tengah).
+type monthWrite struct {
+	paths []string
+	err   error
+	took  time.Duration
+}
+
+// runOrdered menjalankan work(0..n-1) dengan paling banyak jobs goroutine dan
+// memanggil done berurutan indeks begitu hasilnya siap. Error dari done
+// menghentikan pengiriman pekerjaan baru; pekerjaan yang sedang jalan dibiarkan selesai.
+func runOrdered(n, jobs int, work func(i int) monthWrite, done func(i int, w monthWrite) error) error {
+	if jobs < 1 {
+		jobs = 1
+	}
+	results := make([]chan monthWrite, n)
+	for i := range results {
+		results[i] = make(chan monthWrite, 1)
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		sem := make(chan struct{}, jobs)
+		for i := 0; i < n; i++ {
+			select {
+			case sem <- struct{}{}:
+			case <-stop:
+				return
+			}
+			go func(i int) {
+				defer func() { <-sem }()
+				results[i] <- work(i)
+			}(i)
+		}
+	}()
+	for i := 0; i < n; i++ {
+		if err := done(i, <-results[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Tinggi satu baris teks (pt) untuk font default template.
+const lineHeightPt = 15.0
+
+// writeNames mengisi sel dengan nama-nama (satu per baris) tanpa membuang
+// gaya sel template (font, border, alignment): gaya dibaca sebelum
+// SetCellStr lalu dipasang lagi. Hasil seperti fitNames.
+func writeNames(f *excelize.File, sheet, addr string, names []string, merges []excelize.MergeCell, grow bool) bool {
+	style, err := f.GetCellStyle(sheet, addr)
+	_ = f.SetCellStr(sheet, addr, strings.Join(names, "\n"))
+	if err == nil {
+		_ = f.SetCellStyle(sheet, addr, addr, style)
+	}
+	return fitNames(f, sheet, addr, len(names), merges, grow)
+}
+
+// rowSpan: baris awal & akhir sel (merge vertikal ikut dihitung).
+func rowSpan(merges []excelize.MergeCell, addr string) (int, int) {
+	col, row, _ := excelize.CellNameToCoordinates(addr)
+	for _, m := range merges {
+		c1, r1, err1 := excelize.CellNameToCoordinates(m.GetStartAxis())
+		c2, r2, err2 := excelize.CellNameToCoordinates(m.GetEndAxis())
+		if err1 == nil && err2 == nil && col >= c1 && col <= c2 && row >= r1 && row <= r2 {
+			return r1, r2
+		}
+	}
+	return row, row
+}
+
+// fitNames menyiapkan sel berisi n nama (dipisah "\n"): wrap text diaktifkan
+// di atas gaya sel yang ada. Bila tinggi baris (atau merge) tidak cukup untuk
+// n baris teks: dengan grow baris terakhir ditinggikan, tanpa grow hasilnya
+// false (teks terpotong).
+func fitNames(f *excelize.File, sheet, addr string, n int, merges []excelize.MergeCell, grow bool) bool {
+	if n < 2 {
+		return true
+	}
+	if idx, err := f.GetCellStyle(sheet, addr); err == nil {
+		if st, err := f.GetStyle(idx); err == nil {
+			if st.Alignment == nil {
+				st.Alignment = &excelize.Alignment{}
+			}
+			if !st.Alignment.WrapText {
+				st.Alignment.WrapText = true
+				if id, err := f.NewStyle(st); err == nil {
+					_ = f.SetCellStyle(sheet, addr, addr, id)
+				}
+			}
+		}
+	}
+
+	r1, r2 := rowSpan(merges, addr)
+	var have float64
+	for r := r1; r <= r2; r++ {
+		h, _ := f.GetRowHeight(sheet, r)
+		have += h
+	}
+	need := float64(n) * lineHeightPt
+	if have >= need {
+		return true
+	}
+	if !grow {
+		return false
+	}
+	last, _ := f.GetRowHeight(sheet, r2)
+	_ = f.SetRowHeight(sheet, r2, last+need-have)
+	return true
+}
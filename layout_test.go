@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Gaya sel template (border, alignment tengah) harus bertahan setelah diisi nama.
+func TestWriteNamesKeepsStyle(t *testing.T) {
+	f := excelize.NewFile()
+	defer f.Close()
+	const sheet = "Sheet1"
+	center, err := f.NewStyle(&excelize.Style{
+		Alignment: &excelize.Alignment{Horizontal: "center"},
+		Border:    []excelize.Border{{Type: "bottom", Color: "000000", Style: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = f.SetCellStyle(sheet, "B3", "B4", center)
+	_ = f.SetColStyle(sheet, "C", center) // sel C3 belum ada, gaya dari kolom
+
+	for _, addr := range []string{"B3", "C3"} {
+		writeNames(f, sheet, addr, []string{"Budi"}, nil, false)
+		if got, _ := f.GetCellStyle(sheet, addr); got != center {
+			t.Errorf("%s: indeks gaya %d, ingin %d", addr, got, center)
+		}
+		if v, _ := f.GetCellValue(sheet, addr); v != "Budi" {
+			t.Errorf("%s: nilai %q", addr, v)
+		}
+	}
+
+	// multi-nama: wrap ditambahkan, alignment & border template tetap
+	writeNames(f, sheet, "B4", []string{"Ani", "Budi"}, nil, false)
+	idx, _ := f.GetCellStyle(sheet, "B4")
+	st, err := f.GetStyle(idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Alignment == nil || st.Alignment.Horizontal != "center" || !st.Alignment.WrapText {
+		t.Errorf("B4: alignment %+v, ingin center + wrap", st.Alignment)
+	}
+	if len(st.Border) != 1 {
+		t.Errorf("B4: border hilang: %+v", st.Border)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// rolesConfig: Config dengan batas & pola dari flag (tanpa tanggal), cukup
+// untuk menghitung slot per role seperti GenerateSchedule.
+func rolesConfig() (scheduler.Config, error) {
+	cfg := scheduler.NewConfig(nil)
+	cfg.Services = configuredServiceKeys()
+	cfg.MaxLektor = clamp(*maxLektorFlag, 1, 4)
+	cfg.MaxProkantor = clamp(*maxProkantor, 1, 3)
+	cfg.MaxPemusik = clamp(*maxPemusik, 1, 3)
+	cfg.MaxOn = map[string]map[string]int{
+		"lektor":    maxLektorSvc.clamped(1, 4),
+		"prokantor": maxProkantorSvc.clamped(1, 3),
+		"pemusik":   maxPemusikSvc.clamped(1, 3),
+	}
+	var err error
+	if cfg.Kolektan, err = scheduler.QuotaFromPattern(*kolektanPatternFlag); err != nil {
+		return cfg, fmt.Errorf("pola Kolektan: %w", err)
+	}
+	if cfg.PJemaat, err = scheduler.QuotaFromPattern(*pJemaatPatternFlag); err != nil {
+		return cfg, fmt.Errorf("pola P. Jemaat: %w", err)
+	}
+	return cfg, nil
+}
+
+// printRoles (-listRoles) mencetak cara MappingRole dibaca: grup dasar,
+// ibadah, slot per ibadah (batas & pola bulanan dari flag), status Majelis
+// Pendamping, dan ukuran pool eligible (Penatua/Jemaat, tanpa filter TidakBisa).
+func printRoles(w io.Writer, cfg scheduler.Config, people []Person, maps []RoleMap) error {
+	services := scheduler.ServiceKeys(maps, cfg.Services)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Role\tGrup\tKolom Master\tIbadah\tSlot\tMP\tPool (P/J)")
+	for _, m := range maps {
+		group := "-"
+		if scheduler.IsGroupRole(m.Role) {
+			group = scheduler.BaseRole(m.Role)
+		}
+		var svcs, slots []string
+		for _, svc := range services {
+			if !scheduler.InService(m, svc) {
+				continue
+			}
+			svcs = append(svcs, svc)
+			slots = append(slots, fmt.Sprintf("%s=%d", svc, cfg.SlotsFor(m.Role, svc, time.Time{}, maps)))
+		}
+		mp := ""
+		pen, jem := scheduler.EligibleFor(people, scheduler.NormKey(m.SourceColumn), time.Time{})
+		pool := fmt.Sprintf("%d (%d/%d)", len(pen)+len(jem), len(pen), len(jem))
+		if scheduler.IsMajelisPendamping(m.Role) {
+			mp = "ya"
+			pool = fmt.Sprintf("%d (%d/-)", len(pen), len(pen)) // wajib Penatua
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", m.Role, group, m.SourceColumn,
+			strings.Join(svcs, ","), strings.Join(slots, " "), mp, pool)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ==================== Level log (-quiet / -v / -debug) ====================
+
+type logLevel int
+
+const (
+	levelQuiet   logLevel = iota // hanya baris SUKSES (dan ERROR di stderr)
+	levelNormal                  // default
+	levelVerbose                 // -v
+	levelDebug                   // -debug: verbose + path & waktu proses
+)
+
+var (
+	logLvl                = levelNormal
+	logOut      io.Writer = os.Stdout
+	progressOut io.Writer = os.Stderr // stdout tetap bersih untuk -print & skrip
+)
+
+// initLogLevel membaca -quiet/-v/-debug; -quiet tidak bisa digabung dengan keduanya.
+func initLogLevel(quiet, verbose, debug bool) error {
+	switch {
+	case quiet && (verbose || debug):
+		return errors.New("-quiet tidak bisa digabung dengan -v atau -debug")
+	case quiet:
+		logLvl = levelQuiet
+	case debug:
+		logLvl = levelDebug
+	case verbose:
+		logLvl = levelVerbose
+	default:
+		logLvl = levelNormal
+	}
+	return nil
+}
+
+// logWriter: tujuan output untuk level lv (io.Discard bila di bawah level aktif),
+// mis. untuk Config.Log scheduler.
+func logWriter(lv logLevel) io.Writer {
+	if logLvl < lv {
+		return io.Discard
+	}
+	return logOut
+}
+
+func infof(format string, a ...any)    { fmt.Fprintf(logWriter(levelNormal), format, a...) }
+func infoln(a ...any)                  { fmt.Fprintln(logWriter(levelNormal), a...) }
+func verbosef(format string, a ...any) { fmt.Fprintf(logWriter(levelVerbose), format, a...) }
+func debugf(format string, a ...any)   { fmt.Fprintf(logWriter(levelDebug), format, a...) }
+
+// progressf: indikator kemajuan run panjang (-months) ke stderr; dibungkam -quiet.
+func progressf(format string, a ...any) {
+	if logLvl >= levelNormal {
+		fmt.Fprintf(progressOut, format, a...)
+	}
+}
+
+// noticef: baris yang harus selalu terlihat (mis. seed untuk reproduksi);
+// dengan -quiet ke stderr, jadi stdout tetap hanya baris SUKSES.
+func noticef(format string, a ...any) {
+	w := logOut
+	if logLvl < levelNormal {
+		w = progressOut
+	}
+	fmt.Fprintf(w, format, a...)
+}
+
+// success mencetak baris SUKSES; tetap tampil dengan -quiet.
+func success(path string) { fmt.Fprintln(logOut, paint(ansiGreen, tr("SUKSES:")), path) }
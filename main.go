@@ -1,97 +1,274 @@
 package main
 
 import (
+	"bufio"
 	"errors"
 	"flag"
 	"fmt"
-	"log"
-	"math/rand"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/xuri/excelize/v2"
+
+	"jadwal-petugas-cli/scheduler"
 )
 
 // ==================== Types ====================
 
-type RoleMap struct {
-	Role         string
-	SourceColumn string
-	Service      string // "07" | "10" | "both"
-	Slots07      int
-	Slots10      int
-}
-
-type Person struct {
-	Name      string
-	IsPenatua bool
-	Marks     map[string]bool // normalized header -> eligible
-}
-
-type Assignment = map[time.Time]map[string]map[string][]string // date -> service -> role -> names
+// Tipe inti ada di package scheduler (bisa dipakai tanpa CLI).
+type (
+	RoleMap    = scheduler.RoleMap
+	Person     = scheduler.Person
+	Assignment = scheduler.Assignment
+)
 
 // ==================== Flags ====================
 
 var (
+	configFlag      = flag.String("config", "", "File konfigurasi .json/.yaml (kunci = nama flag; flag di command line menang)")
+	printConfigFlag = flag.Bool("printConfig", false, "Cetak konfigurasi efektif (file -config + flag command line) sebagai JSON lalu keluar")
+
 	bulanFlag   = flag.String("bulan", "", "Bulan (1-12 atau nama Indonesia, wajib)")
 	tahunFlag   = flag.Int("tahun", 0, "Tahun (wajib)")
 	tanggalFlag = flag.Int("tgl", 0, "Tanggal (opsional)")
+	datesFlag   = flag.String("dates", "", "Daftar tanggal ibadah khusus yyyy-mm-dd dipisah koma (menggantikan daftar hari Minggu)")
+	skipFlag    = flag.String("skipDates", "", "Tanggal yyyy-mm-dd dipisah koma yang dilewati dari daftar hari Minggu (mis. ibadah gabungan)")
+	lockFlag    = flag.String("lockDates", "", "Tanggal yyyy-mm-dd dipisah koma yang isinya diambil dari file -merge dan tidak diubah (petugasnya tetap dihitung untuk cooldown)")
+	tzFlag      = flag.String("tz", "Asia/Jakarta", "Zona waktu IANA untuk tanggal & jam ibadah (mis. Asia/Makassar); kosong = zona sistem")
+	weekdayFlag = flag.String("weekday", "Minggu", "Hari ibadah yang dijadwalkan per bulan: nama hari (Minggu, Sabtu, ...) atau 0-6 (0=Minggu)")
+	monthsFlag  = flag.String("months", "", "Generate beberapa bulan sekaligus untuk -tahun, mis. \"8-12\" atau \"8,10,12\" (satu file per bulan)")
+	combineFlag = flag.Bool("combine", false, "Dengan -months/-quarter: satu file .xlsx, satu sheet per bulan (salinan sheet template)")
+	quarterFlag = flag.Int("quarter", 0, "Generate satu kuartal (1-4) dari -tahun: tiga bulan, satu file per bulan (seperti -months)")
+	jobsFlag    = flag.Int("jobs", 0, "Dengan -months/-quarter: jumlah bulan yang ditulis bersamaan (0=jumlah CPU, 1=berurutan)")
 
 	maxLektorFlag = flag.Int("maxLektor", 2, "Jumlah Lektor per ibadah (default 2, maks 4)")
 	maxProkantor  = flag.Int("maxProkantor", 2, "Jumlah Prokantor (default 2, maks 3)")
 	maxPemusik    = flag.Int("maxPemusik", 2, "Jumlah Pemusik (default 2, maks 3)")
 
+	// Batas per ibadah, menimpa -maxLektor dst untuk ibadah yang disebut
+	maxLektorSvc    = serviceMaxFlag("maxLektorService", "Jumlah Lektor per ibadah tertentu: service=jumlah dipisah koma (mis. 07=1,10=2); ibadah lain pakai -maxLektor")
+	maxProkantorSvc = serviceMaxFlag("maxProkantorService", "Jumlah Prokantor per ibadah tertentu (mis. 07=1,10=2); ibadah lain pakai -maxProkantor")
+	maxPemusikSvc   = serviceMaxFlag("maxPemusikService", "Jumlah Pemusik per ibadah tertentu (mis. 07=1,10=2); ibadah lain pakai -maxPemusik")
+
 	seedFlag     = flag.Int64("seed", 0, "Seed RNG (opsional, 0=acak)")
+	seedFileFlag = flag.String("seedFile", "", "File seed: dipakai ulang bila ada (tanpa -seed), atau seed acak disimpan ke sini")
 	outdirFlag   = flag.String("outdir", "", "Folder output")
+	outNameFlag  = flag.String("outName", "", "Pola nama file output: {month} {mm} {year} {date} {timestamp} (default JadwalPetugas_{month}_{timestamp})")
 	templateName = flag.String("template", "TemplateOutput.xlsx", "Nama template")
+	autoTemplate = flag.Bool("autoTemplate", false, "Bila template tidak ditemukan, buat template minimal dari MappingRole")
+	sheetFlag    = flag.String("sheet", "Jadwal Bulanan", "Nama sheet jadwal di template (juga dipakai membaca -prevSchedule .xlsx)")
 
 	// Tambahan: jumlah baris header yang discan placeholder-nya
-	headerRowsFlag = flag.Int("headerRows", 30, "Jumlah baris atas untuk scan placeholder header (default 30)")
-	masterOverride  = flag.String("master", "", "Path Master.xlsx khusus")
+	headerRowsFlag  = flag.Int("headerRows", 30, "Jumlah baris atas untuk scan placeholder header (default 30)")
+	dateColsFlag    = flag.Int("dateColumns", 5, "Jumlah kolom tanggal di template (kolom tak terpakai disembunyikan)")
+	startColFlag    = flag.String("startColumn", "B", "Kolom tanggal pertama di template (mis. B)")
+	masterOverride  = flag.String("master", "", "Path Master.xlsx khusus (.xlsx, .ods, atau folder berisi Petugas.csv & MappingRole.csv)")
+	requirePenatua  = flag.Bool("requirePenatua", false, "Error bila kolom Penatua tidak ada, atau tidak ada satu pun Penatua padahal MappingRole punya Majelis Pendamping")
+	normalizeNames  = flag.Bool("normalizeNames", false, "Rapatkan spasi pada nama Petugas (dan Pasangan/Hindari); baris yang namanya sama tanpa beda huruf besar/kecil digabung kecuali -onDuplicate berkata lain")
+	onDuplicateFlag = flag.String("onDuplicate", "", "Baris Petugas bernama sama (setelah normalisasi): merge, error, atau warn (default: merge bila -normalizeNames, selain itu warn)")
+	markersFlag     = flag.String("markers", "", "Token tambahan penanda eligible di sheet Petugas, dipisah koma (mis. v,✓,bisa); x/1/true/ya tetap berlaku")
 	forceMasterCopy = flag.Bool("forceMasterCopy", false, "Paksa salin Master.xlsx")
+	mergeFlag       = flag.String("merge", "", "Perbarui file jadwal .xlsx yang sudah ada: hanya sel role/tanggal hasil run ini yang ditulis (pengganti salinan template)")
+	autoRowHeight   = flag.Bool("autoRowHeight", false, "Tinggikan baris template bila jumlah nama dalam sel melebihi tinggi baris")
+	clampToTemplate = flag.Bool("clampToTemplate", false, "Turunkan -maxLektor/-maxProkantor/-maxPemusik ke jumlah baris yang tersedia di template")
+
+	// Preflight: validasi Master.xlsx saja, tanpa generate
+	validateFlag = flag.Bool("validate", false, "Validasi Master.xlsx tanpa generate (tidak perlu -bulan/-tahun)")
+	listRoles    = flag.Bool("listRoles", false, "Cetak tabel role MappingRole (grup, ibadah, slot per ibadah, MP, ukuran pool eligible) lalu keluar (tidak perlu -bulan/-tahun)")
+	matrixFlag   = flag.String("matrix", "", "Tulis matriks eligibility petugas x role ke file .xlsx/.csv lalu keluar (tidak perlu -bulan/-tahun)")
+	genTemplate  = flag.Bool("genTemplate", false, "Buat file -template dari MappingRole (blok per ibadah) lalu keluar (tidak perlu -bulan/-tahun)")
+
+	// Format output
+	formatFlag  = flag.String("format", "xlsx", "Format output: xlsx | json")
+	icsFlag     = flag.Bool("ics", false, "Tulis juga file kalender .ics di samping output")
+	csvFlag     = flag.Bool("csv", false, "Tulis juga file .csv (tanggal,ibadah,role,nama) di samping output")
+	txtFlag     = flag.Bool("txt", false, "Tulis juga roster teks .txt (siap tempel ke WhatsApp) di samping output")
+	mdFlag      = flag.Bool("md", false, "Tulis juga dokumen Markdown .md (satu tabel per tanggal) di samping output")
+	slipsFlag   = flag.Bool("slips", false, "Tulis juga slip per petugas .slips.txt (tanggal, ibadah, role tiap orang) di samping output")
+	countsFlag  = flag.Bool("slotCounts", false, "Beri anotasi (terisi/diminta) per role di ekspor -csv, -txt, dan -md")
+	digestFlag  = flag.Bool("digest", false, "Tulis juga ringkasan per keluarga role .digest.txt (Lektor, Prokantor, ...) untuk koordinator tim")
+	printFlag   = flag.Bool("print", false, "Dry-run: cetak jadwal sebagai tabel per tanggal/ibadah/role ke layar tanpa menulis file apa pun (cocok dengan -seed)")
+	explainFlag = flag.Bool("explain", false, "Tulis juga jejak keputusan picker .explain.json (tahap, ukuran pool, kandidat dilewati) di samping output")
+	langFlag    = flag.String("lang", "id", "Bahasa teks output (nama hari/bulan, label ringkasan): id | en")
+
+	// Rekap penugasan per orang
+	reportFlag        = flag.Bool("report", false, "Cetak rekap jumlah penugasan per orang setelah generate")
+	reportMaxWarnFlag = flag.Int("reportMaxWarn", 0, "Tandai orang yang bertugas lebih dari N kali (0=nonaktif)")
 
 	verboseFlag = flag.Bool("v", false, "Verbose mode")
+	quietFlag   = flag.Bool("quiet", false, "Hanya cetak baris SUKSES/ERROR (tanpa ringkasan, WARN, dan INFO)")
+	debugFlag   = flag.Bool("debug", false, "Verbose + path file yang dipakai dan waktu proses per bulan")
+	colorFlag   = flag.String("color", "auto", "Warna ANSI di log & rekap (hijau terisi, kuning relax/WARN, merah kosong): auto (hanya di terminal, mati bila NO_COLOR diset) | always | never")
+	confirmFlag = flag.Bool("confirm", false, "Tampilkan tanggal yang terdeteksi lalu minta konfirmasi y/n sebelum generate")
 
 	kolektanPatternFlag = flag.String("kolektanPattern", "2b", "Pola Kolektan (1a..4e)")
 	pJemaatPatternFlag  = flag.String("pjemaatPattern", "3a", "Pola P. Jemaat (1a..4e)")
 
+	// Override pola per tanggal (mis. Minggu Perjamuan), flag boleh diulang
+	kolektanPatternOn = patternOnFlag("kolektanPatternOn", "Pola Kolektan untuk tanggal tertentu: yyyy-mm-dd=kode (boleh diulang/dipisah koma)")
+	pJemaatPatternOn  = patternOnFlag("pjemaatPatternOn", "Pola P. Jemaat untuk tanggal tertentu: yyyy-mm-dd=kode (boleh diulang/dipisah koma)")
+
+	// Penugasan tetap (mis. Lektor tamu), flag boleh diulang
+	pinFlag = pinListFlag("pin", "Tempatkan orang di role/ibadah/tanggal sebelum pengisian acak: yyyy-mm-dd:service:Role=Nama (boleh diulang); pin tidak valid diberi WARN")
+
+	// Label liturgis per tanggal (Perjamuan Kudus, Baptisan, ...), flag boleh diulang
+	labelDatesFlag = dateLabelsFlag("labelDates", "Label tanggal untuk placeholder {Label} di header template: yyyy-mm-dd=label (boleh diulang, mis. 2025-09-07=Perjamuan Kudus); tanggal lain {Label} kosong")
+
 	// Hardening flags
 	strictCompositionFlag = flag.Bool("strictComposition", false, "Strict komposisi P/J: bila kuota tidak tercapai, sisanya kosong (tanpa relax-any)")
+	strictWarnFlag        = flag.Int("strictWarn", 3, "Dengan -strictComposition: saran melonggarkan pola bila slot Kolektan/P. Jemaat kosong lebih dari N")
+	maxAttemptsFlag       = flag.Int("maxAttempts", 1, "Dengan -strictComposition: coba hingga N urutan acak per tanggal untuk komposisi P/J, pakai yang slot kosongnya paling sedikit")
+	substitutesFlag       = flag.Int("substitutes", 0, "Jumlah cadangan per role per ibadah: orang eligible yang belum bertugas hari itu, ditulis \"(Cadangan: ...)\" di samping petugas (tidak dihitung ke -maxPerPerson)")
+	optimalFlag           = flag.Bool("optimal", false, "Bila pengisian Kolektan/P. Jemaat (greedy) kurang penuh, cari ulang dengan bipartite matching (jumlah slot terisi maksimal)")
 	noRelaxB2BFlag        = flag.Bool("noRelaxB2B", false, "Nonaktifkan relax back-to-back (prefer anti-B2B wajib dipatuhi)")
+	cooldownWeeksFlag     = flag.Int("cooldownWeeks", 1, "Hindari orang yang bertugas dalam N Minggu terjadwal sebelumnya (default 1, 0=nonaktif)")
+	cooldownPerRoleFlag   = flag.Bool("cooldownPerRole", false, "Cooldown per role dasar: yang jadi Kolektan minggu lalu boleh jadi Lektor minggu ini (default: role apa pun)")
+	maxPerPersonFlag      = flag.Int("maxPerPerson", 0, "Batas total tugas per orang per bulan, berlaku juga di fase relax (0=tanpa batas)")
+	rebalanceFlag         = flag.Bool("rebalance", false, "Setelah generate, tukar petugas yang sering bertugas dengan yang belum mencapai -minPerPerson")
+	minPerPersonFlag      = flag.Int("minPerPerson", 1, "Target minimal (lunak) tugas per orang untuk -rebalance")
+	fairFlag              = flag.Bool("fair", false, "Utamakan kandidat dengan jumlah tugas bulan ini paling sedikit (acak hanya pemecah seri)")
+	biasFlag              = flag.Float64("bias", 0, "Campuran acak (0) dan paling sedikit bertugas dulu (1), 0..1; -fair = -bias 1")
+	stableOrderFlag       = flag.Bool("stableOrder", false, "Urutan kandidat deterministik (hash nama+tanggal+role+seed) pengganti shuffle")
+	historyFlag           = flag.String("history", "", "File riwayat penugasan .json lintas bulan (dibuat bila belum ada, ditambah setelah sukses)")
+	noCrossServiceFlag    = flag.Bool("noCrossService", false, "Larang satu orang bertugas di dua ibadah pada tanggal yang sama (termasuk relax MP)")
+	noSameHouseholdFlag   = flag.Bool("noSameHousehold", false, "Jangan tugaskan dua orang dengan ID Keluarga sama di ibadah yang sama")
+	diffFlag              = flag.String("diff", "", "Bandingkan hasil dengan jadwal lama (.xlsx/.json hasil generate) dan cetak sel yang berubah")
+	prevScheduleFlag      = flag.String("prevSchedule", "", "Jadwal bulan sebelumnya (.xlsx/.json hasil generate) untuk anti-B2B lintas bulan")
+	rolesFlag             = flag.String("roles", "", "Hanya generate role ini, dipisah koma (dicocokkan per role dasar, mis. Lektor,Pemusik); role lain dibiarkan kosong")
+	excludeFlag           = flag.String("exclude", "", "Nama petugas yang dikeluarkan untuk run ini, dipisah koma (tanpa mengubah Master.xlsx)")
 )
 
 func main() {
-	log.SetFlags(0)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+		flag.PrintDefaults()
+		fmt.Fprint(flag.CommandLine.Output(), exitCodesHelp)
+	}
 	flag.Parse()
 	if err := run(); err != nil {
 		fmt.Fprintln(os.Stderr, "ERROR:", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
 	}
 }
 
-func isVerbose() bool { return *verboseFlag }
+func isVerbose() bool { return logLvl >= levelVerbose }
 
 // ==================== run() ====================
 
+// resolveSeed: -seed eksplisit menang; bila 0 dan seedFile ada, pakai isinya;
+// selain itu seed acak. save = seed acak yang perlu disimpan ke seedFile
+// (saveSeed), baru setelah ada output yang berhasil ditulis.
+func resolveSeed(explicit int64, seedFile string) (seed int64, src string, save bool, err error) {
+	if explicit != 0 {
+		return explicit, "-seed", false, nil
+	}
+	if seedFile != "" {
+		b, err := os.ReadFile(seedFile)
+		if err == nil {
+			n, err := strconv.ParseInt(strings.TrimSpace(string(b)), 10, 64)
+			if err != nil || n == 0 {
+				return 0, "", false, fmt.Errorf("isi seedFile %s tidak valid: %q", seedFile, strings.TrimSpace(string(b)))
+			}
+			return n, "dari " + seedFile, false, nil
+		}
+		if !os.IsNotExist(err) {
+			return 0, "", false, fmt.Errorf("membaca seedFile: %w", err)
+		}
+		return time.Now().UnixNano(), "acak, disimpan ke " + seedFile, true, nil
+	}
+	return time.Now().UnixNano(), "acak", false, nil
+}
+
+// saveSeed menulis seed acak ke seedFile (lihat resolveSeed).
+func saveSeed(seed int64, seedFile string) error {
+	if err := os.WriteFile(seedFile, []byte(strconv.FormatInt(seed, 10)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("menulis seedFile: %w", err)
+	}
+	return nil
+}
+
 func run() error {
-	// RNG
-	if *seedFlag != 0 {
-		rand.Seed(*seedFlag)
-	} else {
-		rand.Seed(time.Now().UnixNano())
+	if s := strings.TrimSpace(*configFlag); s != "" {
+		if err := applyConfigFile(s); err != nil {
+			return usageErr(fmt.Errorf("memuat config %s: %w", s, err))
+		}
 	}
-	if *bulanFlag == "" || *tahunFlag == 0 {
-		return errors.New("parameter -bulan dan -tahun wajib; contoh: -bulan Agustus -tahun 2025")
+	if *printConfigFlag {
+		return printEffectiveConfig(os.Stdout)
 	}
-	month, err := parseMonth(*bulanFlag)
-	if err != nil {
-		return err
+	if err := initLogLevel(*quietFlag, *verboseFlag, *debugFlag); err != nil {
+		return usageErr(err)
+	}
+	if err := initColor(*colorFlag); err != nil {
+		return usageErr(err)
+	}
+
+	var month, year int
+	var months []int
+	var weekday time.Weekday
+	format := strings.ToLower(strings.TrimSpace(*formatFlag))
+	explicitDates := strings.TrimSpace(*datesFlag) != ""
+	matrixPath := strings.TrimSpace(*matrixFlag)
+	if !*validateFlag && matrixPath == "" && !*genTemplate && !*listRoles {
+		if q := *quarterFlag; q != 0 {
+			if q < 1 || q > 4 {
+				return usageErr(fmt.Errorf("-quarter harus 1-4, dapat %d", q))
+			}
+			if strings.TrimSpace(*monthsFlag) != "" || explicitDates || *tanggalFlag > 0 || *bulanFlag != "" {
+				return usageErr(errors.New("-quarter tidak bisa digabung dengan -bulan, -months, -tgl, atau -dates"))
+			}
+			if *tahunFlag == 0 {
+				return usageErr(errors.New("-quarter butuh -tahun; contoh: -quarter 3 -tahun 2025"))
+			}
+			months, year = []int{3*q - 2, 3*q - 1, 3 * q}, *tahunFlag
+		} else if s := strings.TrimSpace(*monthsFlag); s != "" {
+			if explicitDates || *tanggalFlag > 0 || *bulanFlag != "" {
+				return usageErr(errors.New("-months tidak bisa digabung dengan -bulan, -tgl, atau -dates"))
+			}
+			if *tahunFlag == 0 {
+				return usageErr(errors.New("-months butuh -tahun; contoh: -months 8-12 -tahun 2025"))
+			}
+			ms, err := parseMonthList(s)
+			if err != nil {
+				return usageErr(err)
+			}
+			months, year = ms, *tahunFlag
+		} else if explicitDates {
+			if *tanggalFlag > 0 {
+				return usageErr(errors.New("-dates tidak bisa digabung dengan -tgl"))
+			}
+		} else {
+			if *bulanFlag == "" || *tahunFlag == 0 {
+				return usageErr(errors.New("parameter -bulan dan -tahun wajib; contoh: -bulan Agustus -tahun 2025"))
+			}
+			m, err := parseMonth(*bulanFlag)
+			if err != nil {
+				return usageErr(err)
+			}
+			month, year = m, *tahunFlag
+		}
+		wd, err := parseWeekday(*weekdayFlag)
+		if err != nil {
+			return usageErr(err)
+		}
+		weekday = wd
+		if format != "xlsx" && format != "json" {
+			return usageErr(fmt.Errorf("format tidak valid: %s (pilih xlsx atau json)", *formatFlag))
+		}
+		if strings.TrimSpace(*mergeFlag) != "" && format != "xlsx" {
+			return usageErr(errors.New("-merge hanya untuk -format xlsx"))
+		}
+		if _, ok := monthNames[outLang()]; !ok {
+			return usageErr(fmt.Errorf("lang tidak valid: %s (pilih id atau en)", *langFlag))
+		}
 	}
-	year := *tahunFlag
 
 	// Ensure config dir & Master.xlsx
 	docDir := getDocumentsDir()
@@ -101,97 +278,242 @@ func run() error {
 		return fmt.Errorf("membuat folder %s: %w", configDir, err)
 	}
 	exedir, _ := exeDir()
-	cwd, _ := os.Getwd()
 
-	var masterPath string
-	if s := strings.TrimSpace(*masterOverride); s != "" {
-		masterPath = s
-	} else {
-		masterAtConfig := filepath.Join(configDir, "Master.xlsx")
-		candidates := []string{filepath.Join(cwd, "Master.xlsx"), filepath.Join(exedir, "Master.xlsx")}
-		var src string
-		for _, c := range candidates {
-			if _, err := os.Stat(c); err == nil {
-				src = c
-				break
-			}
-		}
-		if *forceMasterCopy {
-			if src == "" {
-				return fmt.Errorf("Master.xlsx sumber tidak ditemukan")
-			}
-			if err := copyFile(src, masterAtConfig); err != nil {
-				return err
-			}
-			if isVerbose() {
-				fmt.Println("Master.xlsx ditimpa dari", src, "->", masterAtConfig)
-			}
-		} else {
-			if _, err := os.Stat(masterAtConfig); os.IsNotExist(err) {
-				if src == "" {
-					return fmt.Errorf("Master.xlsx tidak ditemukan")
-				}
-				if err := copyFile(src, masterAtConfig); err != nil {
-					return err
-				}
-				if isVerbose() {
-					fmt.Println("Master.xlsx disalin ke", masterAtConfig, "dari", src)
-				}
-			}
-		}
-		masterPath = masterAtConfig
+	masterPath, err := resolveMasterPath(configDir, exedir)
+	if err != nil {
+		return withCode(exitNoMaster, err)
 	}
+	debugf("Master: %s\n", masterPath)
 
+	if _, err := onDuplicateMode(*onDuplicateFlag, *normalizeNames); err != nil {
+		return usageErr(err)
+	}
+	addMarkers(*markersFlag)
 	people, mappings, err := loadMaster(masterPath)
 	if err != nil {
-		return fmt.Errorf("memuat Master.xlsx: %w", err)
+		code := exitBadMaster
+		if errors.Is(err, fs.ErrNotExist) {
+			code = exitNoMaster
+		}
+		return withCode(code, fmt.Errorf("memuat Master.xlsx: %w", err))
+	}
+	if s := strings.TrimSpace(*excludeFlag); s != "" {
+		var excluded, unknown []string
+		people, excluded, unknown = excludePeople(people, parseNameList(s))
+		if len(excluded) > 0 {
+			infof("Dikecualikan (-exclude): %s\n", strings.Join(excluded, ", "))
+		}
+		for _, n := range unknown {
+			infof("WARN: -exclude %q tidak ada di sheet Petugas\n", n)
+		}
 	}
 	if len(people) == 0 {
-		return errors.New("Sheet Petugas kosong/invalid")
+		return withCode(exitBadMaster, errors.New("Sheet Petugas kosong/invalid"))
 	}
 	if len(mappings) == 0 {
-		return errors.New("Sheet MappingRole kosong/invalid")
+		return withCode(exitBadMaster, errors.New("Sheet MappingRole kosong/invalid"))
+	}
+	if *validateFlag {
+		return withCode(exitBadMaster, validateMaster(people, mappings))
+	}
+	if matrixPath != "" {
+		if err := writeMatrix(eligibilityMatrix(people, mappings), matrixPath); err != nil {
+			return writeErr(fmt.Errorf("menulis matriks: %w", err))
+		}
+		success(matrixPath)
+		return nil
+	}
+	if *genTemplate {
+		return writeErr(writeGenTemplate(mappings, *templateName, *sheetFlag))
+	}
+	if *listRoles {
+		cfg, err := rolesConfig()
+		if err != nil {
+			return usageErr(err)
+		}
+		return printRoles(os.Stdout, cfg, people, mappings)
+	}
+	// genMaps: role yang di-generate & ditulis; mappings tetap lengkap untuk
+	// membaca jadwal lama (-prevSchedule, -diff, -lockDates, -merge)
+	genMaps := mappings
+	var onlyRoles map[string]bool // nil = semua role
+	if s := strings.TrimSpace(*rolesFlag); s != "" {
+		if genMaps, onlyRoles, err = selectRoles(mappings, parseNameList(s)); err != nil {
+			return usageErr(err)
+		}
+		names := make([]string, len(genMaps))
+		for i, m := range genMaps {
+			names[i] = m.Role
+		}
+		infof("Hanya role (-roles): %s\n", strings.Join(names, ", "))
+	}
+	// RNG: hanya di jalur generate; seedFile baru ditulis setelah bulan pertama tersimpan
+	seedFile := strings.TrimSpace(*seedFileFlag)
+	seed, seedSrc, saveSeedFile, err := resolveSeed(*seedFlag, seedFile)
+	if err != nil {
+		return usageErr(err)
 	}
+	// selalu dicetak (juga dengan -quiet) agar jadwal bisa direproduksi dengan -seed
+	noticef("Seed: %d (%s)\n", seed, seedSrc)
 
-	loc := mustLoc("Asia/Jakarta")
+	loc, err := loadLoc(*tzFlag)
+	if err != nil {
+		return usageErr(err)
+	}
 	var dates []time.Time
-	if *tanggalFlag > 0 {
+	// batches: satu daftar tanggal per file output (lebih dari satu hanya dengan -months)
+	var batches [][]time.Time
+	if len(months) > 0 {
+		for _, m := range months {
+			ds := allWeekdays(year, m, weekday, loc)
+			if len(ds) == 0 {
+				return usageErr(fmt.Errorf("tidak ada hari %s pada bulan %s", dayNames["id"][weekday], monthNameID(m)))
+			}
+			batches = append(batches, ds)
+		}
+	} else if explicitDates {
+		dates, err = parseDateList(*datesFlag, loc)
+		if err != nil {
+			return usageErr(err)
+		}
+	} else if *tanggalFlag > 0 {
 		d, err := safeDate(year, month, *tanggalFlag, loc)
 		if err != nil {
-			return err
+			return usageErr(err)
 		}
 		dates = []time.Time{d}
 	} else {
-		dates = allSundays(year, month, loc)
+		dates = allWeekdays(year, month, weekday, loc)
 		if len(dates) == 0 {
-			return errors.New("tidak ada hari Minggu pada bulan ini")
+			return usageErr(fmt.Errorf("tidak ada hari %s pada bulan ini", dayNames["id"][weekday]))
+		}
+	}
+	if len(batches) == 0 {
+		batches = [][]time.Time{dates}
+	}
+	if s := strings.TrimSpace(*skipFlag); s != "" {
+		if explicitDates || *tanggalFlag > 0 {
+			return usageErr(errors.New("-skipDates hanya untuk daftar hari Minggu per bulan (bukan -dates/-tgl)"))
+		}
+		skip, err := parseDateList(s, loc)
+		if err != nil {
+			return usageErr(fmt.Errorf("-skipDates: %w", err))
+		}
+		if batches, err = skipBatchDates(batches, skip); err != nil {
+			return usageErr(err)
+		}
+	}
+	if len(batches) > 1 {
+		// TidakBisa "dd" hanya untuk bulan pertama, bukan tanggal itu di tiap bulan
+		first := batches[0][0]
+		if names := anchorDayOnly(people, first.Year(), first.Month(), loc); len(names) > 0 {
+			infof("WARN: TidakBisa tanggal saja (dd) dipakai untuk %s %d saja: %s; pakai yyyy-mm-dd untuk bulan lain\n",
+				monthNameID(int(first.Month())), first.Year(), strings.Join(names, ", "))
 		}
 	}
 
 	maxLektor := clamp(*maxLektorFlag, 1, 4)
 	maxPro := clamp(*maxProkantor, 1, 3)
 	maxMus := clamp(*maxPemusik, 1, 3)
+	maxOn := map[string]map[string]int{
+		"lektor":    maxLektorSvc.clamped(1, 4),
+		"prokantor": maxProkantorSvc.clamped(1, 3),
+		"pemusik":   maxPemusikSvc.clamped(1, 3),
+	}
+	if *biasFlag < 0 || *biasFlag > 1 {
+		return usageErr(fmt.Errorf("-bias harus 0..1, dapat %g", *biasFlag))
+	}
+	if *biasFlag > 0 && *fairFlag {
+		return usageErr(errors.New("-bias tidak bisa digabung dengan -fair (-fair sama dengan -bias 1)"))
+	}
+	if *jobsFlag < 0 {
+		return usageErr(fmt.Errorf("-jobs tidak boleh negatif: %d", *jobsFlag))
+	}
+	if *maxAttemptsFlag < 1 {
+		return usageErr(fmt.Errorf("-maxAttempts minimal 1, dapat %d", *maxAttemptsFlag))
+	}
+	if *maxPerPersonFlag < 0 {
+		return usageErr(fmt.Errorf("maxPerPerson tidak boleh negatif: %d", *maxPerPersonFlag))
+	}
+	if *substitutesFlag < 0 {
+		return usageErr(fmt.Errorf("-substitutes tidak boleh negatif: %d", *substitutesFlag))
+	}
+	if *cooldownWeeksFlag < 0 {
+		return usageErr(fmt.Errorf("cooldownWeeks tidak boleh negatif: %d", *cooldownWeeksFlag))
+	}
+	cooldown := *cooldownWeeksFlag
 
-	kPen, kJem, _, err := parsePattern(*kolektanPatternFlag)
+	kPen, kJem, _, err := scheduler.ParsePattern(*kolektanPatternFlag)
 	if err != nil {
-		return fmt.Errorf("pola Kolektan: %w", err)
+		return usageErr(fmt.Errorf("pola Kolektan: %w", err))
 	}
-	pPen, pJem, _, err := parsePattern(*pJemaatPatternFlag)
+	pPen, pJem, _, err := scheduler.ParsePattern(*pJemaatPatternFlag)
 	if err != nil {
-		return fmt.Errorf("pola P. Jemaat: %w", err)
+		return usageErr(fmt.Errorf("pola P. Jemaat: %w", err))
 	}
 
-	if isVerbose() {
-		fmt.Printf("Flags: strictComposition=%v, noRelaxB2B=%v, seed=%d\n", *strictCompositionFlag, *noRelaxB2BFlag, *seedFlag)
-		fmt.Printf("Limits: Lektor=%d Prokantor=%d Pemusik=%d\n", maxLektor, maxPro, maxMus)
-		fmt.Printf("HeaderRows: %d\n", *headerRowsFlag)
-		fmt.Printf("Pattern: Kolektan=%s (P:%d J:%d) | P.Jemaat=%s (P:%d J:%d)\n",
-			*kolektanPatternFlag, kPen, kJem, *pJemaatPatternFlag, pPen, pJem)
+	verbosef("Flags: strictComposition=%v, noRelaxB2B=%v, cooldownWeeks=%d, fair=%v, stableOrder=%v, seed=%d (%s)\n", *strictCompositionFlag, *noRelaxB2BFlag, cooldown, *fairFlag, *stableOrderFlag, seed, seedSrc)
+	verbosef("Limits: Lektor=%d Prokantor=%d Pemusik=%d\n", maxLektor, maxPro, maxMus)
+	if len(maxLektorSvc)+len(maxProkantorSvc)+len(maxPemusikSvc) > 0 {
+		verbosef("Limits per ibadah: Lektor=%s Prokantor=%s Pemusik=%s\n", maxLektorSvc, maxProkantorSvc, maxPemusikSvc)
+	}
+	verbosef("HeaderRows: %d\n", *headerRowsFlag)
+	verbosef("Pattern: Kolektan=%s (P:%d J:%d) | P.Jemaat=%s (P:%d J:%d)\n",
+		*kolektanPatternFlag, kPen, kJem, *pJemaatPatternFlag, pPen, pJem)
+	if len(kolektanPatternOn) > 0 || len(pJemaatPatternOn) > 0 {
+		verbosef("Pattern per tanggal: Kolektan=%s | P.Jemaat=%s\n", kolektanPatternOn, pJemaatPatternOn)
+	}
+	patternOn := map[string]patternOverrides{"kolektan": kolektanPatternOn, "pjemaat": pJemaatPatternOn}
+	for key, po := range patternOn {
+		for ds := range po {
+			if !dateInBatches(batches, ds) {
+				infof("WARN: override pola %s tanggal %s tidak ada di jadwal; diabaikan\n", strings.Title(key), ds)
+			}
+		}
+	}
+	for ds := range labelDatesFlag {
+		if !dateInBatches(batches, ds) {
+			infof("WARN: -labelDates tanggal %s tidak ada di jadwal; diabaikan\n", ds)
+		}
 	}
 
-	assign := make(Assignment)
-	if err := generate(assign, dates, people, mappings, maxLektor, maxPro, maxMus, loc, isVerbose(), kPen, kJem, pPen, pJem); err != nil {
-		return err
+	var prior map[string][]time.Time
+	if s := strings.TrimSpace(*prevScheduleFlag); s != "" {
+		prior, err = loadPrevSchedule(s, mappings, loc)
+		if err != nil {
+			return fmt.Errorf("memuat prevSchedule: %w", err)
+		}
+		verbosef("PrevSchedule: %d petugas dari %s\n", len(prior), s)
+	}
+	var diffOld Assignment
+	if s := strings.TrimSpace(*diffFlag); s != "" {
+		if diffOld, err = loadScheduleAssign(s, mappings, loc); err != nil {
+			return fmt.Errorf("memuat -diff: %w", err)
+		}
+	}
+	var locked Assignment
+	if s := strings.TrimSpace(*lockFlag); s != "" {
+		if len(batches) > 1 {
+			return usageErr(errors.New("-lockDates hanya untuk satu file output (tidak bisa dengan -months)"))
+		}
+		if locked, err = loadLockedDates(s, strings.TrimSpace(*mergeFlag), batches[0], mappings, loc); err != nil {
+			return usageErr(err)
+		}
+		locked = filterRoles(locked, onlyRoles, true)
+		verbosef("LockDates: %d tanggal dari %s\n", len(locked), *mergeFlag)
+	}
+	var hist *historyStore
+	historyPath := strings.TrimSpace(*historyFlag)
+	if historyPath != "" {
+		if hist, err = loadHistory(historyPath); err != nil {
+			return fmt.Errorf("memuat history: %w", err)
+		}
+		served, err := hist.served(loc)
+		if err != nil {
+			return fmt.Errorf("memuat history: %w", err)
+		}
+		prior = mergeServed(prior, served)
+		verbosef("History: %d entri dari %s\n", len(hist.Entries), historyPath)
 	}
 
 	// Output
@@ -199,48 +521,375 @@ func run() error {
 	if strings.TrimSpace(outDir) == "" {
 		outDir = baseDir
 	}
-	if err := os.MkdirAll(outDir, 0o755); err != nil {
-		return err
+	if !*printFlag {
+		if err := os.MkdirAll(outDir, 0o755); err != nil {
+			return writeErr(err)
+		}
 	}
 	now := time.Now().In(loc)
-	outName := fmt.Sprintf("JadwalPetugas_%s_%02d.%02d.%02d.xlsx", monthNameID(month), now.Hour(), now.Minute(), now.Second())
-	outPath := filepath.Join(outDir, outName)
+	outPattern := strings.TrimSpace(*outNameFlag)
+	if outPattern == "" {
+		outPattern = defaultOutName
+	}
+	var kept Assignment // -roles dengan -merge: role lain yang sudah terisi di file itu
+	if format == "xlsx" {
+		firstCol, err := templateDateColumns()
+		if err != nil {
+			return usageErr(err)
+		}
+		tplPath, err := findTemplate(exedir, *templateName)
+		if merge := strings.TrimSpace(*mergeFlag); merge != "" {
+			if len(batches) > 1 {
+				return usageErr(errors.New("-merge hanya untuk satu file output (tidak bisa dengan -months)"))
+			}
+			if err := checkMerge(merge, *sheetFlag, batches[0], loc); err != nil {
+				return usageErr(err)
+			}
+			if onlyRoles != nil {
+				if kept, err = loadKeptRoles(merge, batches[0], mappings, onlyRoles, loc); err != nil {
+					return err
+				}
+				// tanggal file -merge sebelum run ini ikut cooldown
+				keptDates := make([]time.Time, 0, len(kept))
+				for d := range kept {
+					keptDates = append(keptDates, d)
+				}
+				prior = mergeServed(prior, servedFromAssign(kept, keptDates))
+			}
+			tplPath, err = merge, nil
+		} else if err != nil && !*autoTemplate && !*printFlag {
+			return err // gagal sebelum generate, bukan saat menyalin template
+		}
+		// template bawaan (-autoTemplate) selalu punya baris untuk setiap role
+		if err == nil {
+			limits := []templateLimit{
+				{"lektor", "-maxLektor", &maxLektor, maxOn["lektor"]},
+				{"prokantor", "-maxProkantor", &maxPro, maxOn["prokantor"]},
+				{"pemusik", "-maxPemusik", &maxMus, maxOn["pemusik"]},
+			}
+			if err := checkTemplateCapacity(tplPath, *sheetFlag, genMaps, limits, *clampToTemplate); err != nil {
+				return usageErr(err)
+			}
+		}
+		for _, dates := range batches {
+			if len(dates) > *dateColsFlag {
+				last, _ := excelize.ColumnNumberToName(firstCol + *dateColsFlag - 1)
+				return usageErr(fmt.Errorf("%d tanggal (%s) melebihi %d kolom tanggal template (%s..%s); naikkan -dateColumns atau pakai template lain",
+					len(dates), monthNameID(int(dates[0].Month())), *dateColsFlag, strings.ToUpper(*startColFlag), last))
+			}
+		}
+	}
+	if _, err := expandOutName(outPattern, 1, 2000, now); err != nil {
+		return usageErr(err) // pola salah ketahuan sebelum generate
+	}
+	if len(batches) > 1 && !strings.Contains(outPattern, "{month}") && !strings.Contains(outPattern, "{mm}") {
+		return usageErr(errors.New("-outName dengan -months wajib memuat {month} atau {mm} agar file tiap bulan tidak saling menimpa"))
+	}
+	// -combine: satu workbook untuk semua bulan, nama dengan rentang bulan
+	var combinedPath string
+	if *combineFlag && !*printFlag {
+		switch {
+		case len(batches) < 2:
+			return usageErr(errors.New("-combine hanya untuk beberapa bulan (-months atau -quarter)"))
+		case format != "xlsx":
+			return usageErr(errors.New("-combine hanya untuk -format xlsx"))
+		case strings.TrimSpace(*mergeFlag) != "":
+			return usageErr(errors.New("-combine tidak bisa digabung dengan -merge"))
+		}
+		first, last := batches[0][0], batches[len(batches)-1][0]
+		base, err := expandOutNameRange(outPattern, int(first.Month()), int(last.Month()), first.Year(), now)
+		if err != nil {
+			return usageErr(err)
+		}
+		combinedPath = filepath.Join(outDir, base+".xlsx")
+		_ = os.Remove(combinedPath) // bulan pertama selalu mulai dari template
+	}
+	if *confirmFlag && !confirmDates(batches, os.Stdin) {
+		fmt.Println("Dibatalkan.")
+		return nil
+	}
+
+	strictEmpty := 0 // slot Kolektan/P. Jemaat kosong karena -strictComposition, semua bulan
+	// Tahap 1 (berurutan): generate tiap bulan. Cooldown bulan berikutnya
+	// bergantung pada hasil bulan ini, jadi tahap ini tidak bisa paralel.
+	var runs []monthRun
+	for bi, dates := range batches {
+		start := time.Now()
+		month := int(dates[0].Month())
+		if len(batches) > 1 {
+			progressf("Generate %d/%d: %s %d...\n", bi+1, len(batches), monthNameID(month), dates[0].Year())
+		}
+		plan := scheduler.SlotPlan{}
+		var trace *scheduler.Trace
+		if *explainFlag {
+			trace = &scheduler.Trace{}
+		}
+		var backups Assignment // -substitutes
+		if *substitutesFlag > 0 {
+			backups = Assignment{}
+		}
+		assign, err := generate(dates, people, genMaps, prior, maxLektor, maxPro, maxMus, maxOn, cooldown, seed,
+			scheduler.Quota{Penatua: kPen, Jemaat: kJem}, scheduler.Quota{Penatua: pPen, Jemaat: pJem}, patternOn, locked, kept, plan, trace, backups)
+		if err != nil {
+			return err
+		}
+		if *rebalanceFlag {
+			swaps := rebalance(assign, dates, locked, kept, people, genMaps, *minPerPersonFlag, cooldown, *maxPerPersonFlag, *noSameHouseholdFlag)
+			printSwaps(swaps)
+		}
+		// cadangan dicek ulang terhadap hasil akhir (rebalance bisa menukar
+		// cadangan ke slot utama atau memindahkan orang yang dihindarinya)
+		scheduler.PruneBackups(backups, assign, kept, people, *noSameHouseholdFlag)
+		if *reportFlag {
+			printReport(assign, dates, people, genMaps, *reportMaxWarnFlag)
+		}
+		gaps := findGaps(plan, assign, dates)
+		printGaps(gaps)
+		if *strictCompositionFlag {
+			strictEmpty += printStrictGaps(gaps, *strictWarnFlag)
+		}
+		// audit keamanan: satu orang di lebih dari satu ibadah pada tanggal yang sama
+		if cross := auditCrossService(assign, dates, mappings); len(cross) > 0 {
+			if *noCrossServiceFlag || isVerbose() {
+				for _, c := range cross {
+					infoln("AUDIT:", c)
+				}
+			}
+			if *noCrossServiceFlag {
+				return withCode(exitInfeasible, fmt.Errorf("audit -noCrossService gagal: %d kasus bertugas lintas ibadah", len(cross)))
+			}
+			infof("INFO: %d kasus bertugas lintas ibadah di tanggal yang sama (diizinkan; pakai -noCrossService untuk melarang)\n", len(cross))
+		}
+		if diffOld != nil {
+			printDiff(diffOld, assign, dates, strings.TrimSpace(*diffFlag))
+		}
+		if *printFlag {
+			// dry-run: tanpa salin template, tanpa file apa pun
+			if err := printSchedule(os.Stdout, scheduleOf(assign, backups, dates, genMaps), plan, *countsFlag, genMaps); err != nil {
+				return err
+			}
+		}
+		// bulan berikutnya (-months): cooldown melanjutkan dari jadwal bulan ini
+		prior = mergeServed(prior, servedFromAssign(assign, dates))
+		if !*printFlag {
+			runs = append(runs, monthRun{dates: dates, assign: assign, backups: backups, plan: plan, trace: trace})
+		}
+		debugf("Bulan %s: generate %s\n", monthNameID(month), time.Since(start).Round(time.Millisecond))
+	}
+
+	// Tahap 2: tulis file tiap bulan, paling banyak -jobs bulan bersamaan.
+	// Baris SUKSES & -history tetap berurutan per bulan.
+
+	// -months: template dibaca & diindeks sekali untuk semua bulan; gagal
+	// dibaca -> tanpa cache, writer melaporkan error seperti biasa
+	var tc *templateCache
+	if len(runs) > 1 && format == "xlsx" {
+		if tplPath, err := findTemplate(exedir, *templateName); err == nil {
+			tc, _ = loadTemplateCache(tplPath, *sheetFlag)
+		}
+	}
+	writeMonth := func(r monthRun) (written []string, err error) {
+		dates, assign, backups, plan, trace := r.dates, r.assign, r.backups, r.plan, r.trace
+		month := int(dates[0].Month())
+		outBase, err := expandOutName(outPattern, month, dates[0].Year(), now)
+		if err != nil {
+			return nil, err
+		}
+
+		sched := scheduleOf(assign, backups, dates, genMaps)
+		var countPlan scheduler.SlotPlan // nil = tanpa anotasi (terisi/diminta)
+		if *countsFlag {
+			countPlan = plan
+		}
+		if *icsFlag {
+			icsPath := filepath.Join(outDir, outBase+".ics")
+			if err := writeICS(sched, icsPath); err != nil {
+				return written, writeErr(fmt.Errorf("menulis .ics: %w", err))
+			}
+			written = append(written, icsPath)
+		}
+		if *csvFlag {
+			csvPath := filepath.Join(outDir, outBase+".csv")
+			if err := writeCSV(sched, countPlan, csvPath); err != nil {
+				return written, writeErr(fmt.Errorf("menulis .csv: %w", err))
+			}
+			written = append(written, csvPath)
+		}
+		if *txtFlag {
+			txtPath := filepath.Join(outDir, outBase+".txt")
+			if err := writeTXT(sched, countPlan, txtPath); err != nil {
+				return written, writeErr(fmt.Errorf("menulis .txt: %w", err))
+			}
+			written = append(written, txtPath)
+		}
+		if trace != nil {
+			explainPath := filepath.Join(outDir, outBase+".explain.json")
+			if err := writeExplain(trace, explainPath); err != nil {
+				return written, writeErr(fmt.Errorf("menulis .explain.json: %w", err))
+			}
+			written = append(written, explainPath)
+		}
+		if *mdFlag {
+			mdPath := filepath.Join(outDir, outBase+".md")
+			if err := writeMarkdown(sched, countPlan, genMaps, mdPath); err != nil {
+				return written, writeErr(fmt.Errorf("menulis .md: %w", err))
+			}
+			written = append(written, mdPath)
+		}
+		if *slipsFlag {
+			slipsPath := filepath.Join(outDir, outBase+".slips.txt")
+			if err := writeSlips(sched, slipsPath); err != nil {
+				return written, writeErr(fmt.Errorf("menulis .slips.txt: %w", err))
+			}
+			written = append(written, slipsPath)
+		}
+		if *digestFlag {
+			digestPath := filepath.Join(outDir, outBase+".digest.txt")
+			if err := writeDigest(sched, genMaps, digestPath); err != nil {
+				return written, writeErr(fmt.Errorf("menulis .digest.txt: %w", err))
+			}
+			written = append(written, digestPath)
+		}
+
+		var outPath string
+		if format == "json" {
+			outPath = filepath.Join(outDir, outBase+".json")
+			if err := writeJSON(assign, backups, dates, outPath); err != nil {
+				return written, writeErr(err)
+			}
+		} else {
+			outPath = filepath.Join(outDir, outBase+".xlsx")
+			if merge := strings.TrimSpace(*mergeFlag); merge != "" {
+				outPath = merge // diperbarui di tempat
+			}
+			monthSheet := ""
+			if combinedPath != "" {
+				outPath, monthSheet = combinedPath, monthNameID(month)
+			}
+			writeDates := dates
+			if locked != nil {
+				writeDates = unlockedDates(dates, locked) // kolom tanggal terkunci tidak disentuh
+			}
+			if err := writeTemplateAware(assign, backups, genMaps, writeDates, exedir, *templateName, *sheetFlag, outPath, monthSheet, loc, isVerbose(), tc); err != nil {
+				return written, writeErr(err)
+			}
+		}
+		if combinedPath == "" {
+			written = append(written, outPath)
+		}
+		return written, nil
+	}
 
-	if err := writeTemplateAware(assign, mappings, dates, exedir, *templateName, outPath, loc, isVerbose()); err != nil {
+	jobs := *jobsFlag
+	if jobs == 0 {
+		jobs = runtime.NumCPU()
+	}
+	if combinedPath != "" {
+		jobs = 1 // satu workbook: sheet bulan ditambahkan berurutan
+	}
+	start := time.Now()
+	var busy time.Duration // jumlah waktu tulis per bulan; busy/wall = percepatan dari paralel
+	if err := runOrdered(len(runs), jobs, func(i int) monthWrite {
+		t := time.Now()
+		paths, err := writeMonth(runs[i])
+		return monthWrite{paths: paths, err: err, took: time.Since(t)}
+	}, func(i int, w monthWrite) error {
+		for _, p := range w.paths {
+			success(p)
+		}
+		if w.err != nil {
+			return w.err
+		}
+		busy += w.took
+		if saveSeedFile {
+			if err := saveSeed(seed, seedFile); err != nil {
+				return writeErr(err)
+			}
+			saveSeedFile = false
+		}
+		dates := runs[i].dates
+		if hist != nil {
+			hist.record(runs[i].assign, dates, onlyRoles)
+			if err := hist.save(historyPath); err != nil {
+				return writeErr(fmt.Errorf("menyimpan history: %w", err))
+			}
+		}
+		debugf("Bulan %s: tulis %s\n", monthNameID(int(dates[0].Month())), w.took.Round(time.Millisecond))
+		return nil
+	}); err != nil {
 		return err
 	}
-	fmt.Println("SUKSES:", outPath)
+	if len(runs) > 1 {
+		wall := time.Since(start)
+		verbosef("Tulis %d bulan (-jobs %d): %s, jumlah waktu per bulan %s (%.1fx)\n",
+			len(runs), jobs, wall.Round(time.Millisecond), busy.Round(time.Millisecond), busy.Seconds()/wall.Seconds())
+	}
+	if combinedPath != "" {
+		if err := finishCombined(combinedPath, *sheetFlag, monthNameID(int(batches[0][0].Month()))); err != nil {
+			return writeErr(err)
+		}
+		success(combinedPath)
+	}
+	if strictEmpty > 0 {
+		// file tetap ditulis; exit code memberi tahu skrip bahwa jadwal belum lengkap
+		return withCode(exitInfeasible, fmt.Errorf("-strictComposition: %d slot Kolektan/P. Jemaat wajib dibiarkan kosong", strictEmpty))
+	}
 	return nil
 }
 
+// resolveMasterPath: -master bila diisi; selain itu config/Master.xlsx,
+// disalin dari CWD/folder exe bila belum ada (atau -forceMasterCopy).
+func resolveMasterPath(configDir, exedir string) (string, error) {
+	if s := strings.TrimSpace(*masterOverride); s != "" {
+		return s, nil
+	}
+	cwd, _ := os.Getwd()
+	masterAtConfig := filepath.Join(configDir, "Master.xlsx")
+	candidates := []string{filepath.Join(cwd, "Master.xlsx"), filepath.Join(exedir, "Master.xlsx")}
+	var src string
+	for _, c := range candidates {
+		if _, err := os.Stat(c); err == nil {
+			src = c
+			break
+		}
+	}
+	if *forceMasterCopy {
+		if src == "" {
+			return "", fmt.Errorf("Master.xlsx sumber tidak ditemukan")
+		}
+		if err := copyFile(src, masterAtConfig); err != nil {
+			return "", err
+		}
+		verbosef("Master.xlsx ditimpa dari %s -> %s\n", src, masterAtConfig)
+	} else {
+		if _, err := os.Stat(masterAtConfig); os.IsNotExist(err) {
+			if src == "" {
+				return "", fmt.Errorf("Master.xlsx tidak ditemukan")
+			}
+			if err := copyFile(src, masterAtConfig); err != nil {
+				return "", err
+			}
+			verbosef("Master.xlsx disalin ke %s dari %s\n", masterAtConfig, src)
+		}
+	}
+	return masterAtConfig, nil
+}
+
 // ==================== loadMaster() ====================
 
+// loadMaster membaca Petugas & MappingRole dari path: .xlsx, .ods, atau
+// folder berisi Petugas.csv & MappingRole.csv (lihat readMasterRows).
 func loadMaster(path string) ([]Person, []RoleMap, error) {
-	f, err := excelize.OpenFile(path)
+	petRows, relRows, err := readMasterRows(path)
 	if err != nil {
 		return nil, nil, err
 	}
-	defer f.Close()
-
-	petugasSheet := findSheet(f, []string{"Petugas"})
-	if petugasSheet == "" {
-		return nil, nil, errors.New("Sheet Petugas tidak ditemukan")
-	}
-	mappingSheet := findSheet(f, []string{"MappingRole"})
-	if mappingSheet == "" {
-		return nil, nil, errors.New("Sheet MappingRole tidak ditemukan")
-	}
-
-	petRows, _ := f.GetRows(petugasSheet)
 	if len(petRows) < 2 {
 		return nil, nil, errors.New("Petugas kosong")
 	}
 
 	// Header index
-	headIdx := map[string]int{}
-	for col, name := range petRows[0] {
-		headIdx[strings.ToLower(strings.TrimSpace(name))] = col
-	}
+	headIdx := indexHeader(petRows[0])
 	nameCol, ok := headIdx["nama"]
 	if !ok {
 		return nil, nil, errors.New("Kolom Nama wajib")
@@ -249,35 +898,66 @@ func loadMaster(path string) ([]Person, []RoleMap, error) {
 	if idx, ok := headIdx["penatua"]; ok {
 		penatuaCol = idx
 	}
+	unavailCol := findHeader(headIdx, []string{"tidakbisa", "tidak bisa"})
+	householdCol := findHeader(headIdx, []string{"keluarga"})
+	partnerCol := findHeader(headIdx, []string{"pasangan"})
+	avoidCol := findHeader(headIdx, []string{"hindari"})
 
 	var people []Person
+	var peopleRows []int // nomor baris sheet per orang, untuk laporan nama ganda
 	for i := 1; i < len(petRows); i++ {
 		row := petRows[i]
 		if nameCol >= len(row) {
 			continue
 		}
-		name := strings.TrimSpace(row[nameCol])
+		name := canonName(row[nameCol], *normalizeNames)
 		if name == "" {
 			continue
 		}
-		p := Person{Name: name, Marks: map[string]bool{}}
+		p := Person{Name: name, Marks: map[string]bool{}, Weights: map[string]int{}}
 		if penatuaCol >= 0 && penatuaCol < len(row) {
 			p.IsPenatua = isMarked(row[penatuaCol])
 		}
-		for k, v := range row {
-			if k >= len(petRows[0]) {
-				continue
+		if unavailCol >= 0 && unavailCol < len(row) {
+			un, err := parseUnavailable(row[unavailCol])
+			if err != nil {
+				return nil, nil, fmt.Errorf("Petugas baris %d (%s): kolom TidakBisa: %w", i+1, name, err)
+			}
+			p.Unavailable = un
+		}
+		if householdCol >= 0 && householdCol < len(row) {
+			p.Household = strings.ToLower(strings.TrimSpace(row[householdCol]))
+		}
+		if partnerCol >= 0 && partnerCol < len(row) {
+			p.Partner = canonName(row[partnerCol], *normalizeNames)
+		}
+		if avoidCol >= 0 && avoidCol < len(row) {
+			p.Avoid = parseNameList(row[avoidCol])
+			for k, n := range p.Avoid {
+				p.Avoid[k] = canonName(n, *normalizeNames)
 			}
-			hdr := strings.TrimSpace(petRows[0][k])
-			if hdr == "" {
+		}
+		// semua kolom header tercatat di Marks (sel kosong/terpotong = false)
+		for k, hdr := range petRows[0] {
+			if scheduler.NormKey(hdr) == "" {
 				continue
 			}
-			p.Marks[normKey(hdr)] = isMarked(v)
+			v := ""
+			if k < len(row) {
+				v = row[k]
+			}
+			w := markWeight(v)
+			p.Weights[scheduler.NormKey(hdr)] = w
+			p.Marks[scheduler.NormKey(hdr)] = w > 0
 		}
 		people = append(people, p)
+		peopleRows = append(peopleRows, i+1)
+	}
+	dupMode, _ := onDuplicateMode(*onDuplicateFlag, *normalizeNames) // sudah divalidasi di run()
+	if people, err = handleDuplicateNames(people, peopleRows, dupMode); err != nil {
+		return nil, nil, err
 	}
 
-	relRows, _ := f.GetRows(mappingSheet)
 	if len(relRows) < 2 {
 		return people, nil, errors.New("Mapping kosong")
 	}
@@ -285,8 +965,17 @@ func loadMaster(path string) ([]Person, []RoleMap, error) {
 	roleCol := findHeader(mh, []string{"role"})
 	srcCol := findHeader(mh, []string{"kolom master", "source"})
 	serviceCol := findHeader(mh, []string{"service"})
-	slots07Col := findHeader(mh, []string{"slots07"})
-	slots10Col := findHeader(mh, []string{"slots10"})
+	priorityCol := findHeader(mh, []string{"priority", "prioritas"})
+	cooldownCol := findHeader(mh, []string{"cooldownweeks", "cooldown"})
+	samePersonCol := findHeader(mh, []string{"sameperson", "same person", "orangsama", "orang sama"})
+	crossCol := findHeader(mh, []string{"crossservice", "cross service", "lintasibadah", "lintas ibadah"})
+	// kolom SlotsXX per service (Slots07, Slots10, Slots17, ...)
+	slotsCols := map[string]int{}
+	for h, idx := range mh {
+		if strings.HasPrefix(h, "slots") && len(h) > len("slots") {
+			slotsCols[normServiceKey(h[len("slots"):])] = idx
+		}
+	}
 	if roleCol < 0 || srcCol < 0 {
 		return people, nil, errors.New("MappingRole wajib ada kolom Role & Kolom Master")
 	}
@@ -302,572 +991,316 @@ func loadMaster(path string) ([]Person, []RoleMap, error) {
 		if role == "" || src == "" {
 			continue
 		}
-		m := RoleMap{Role: role, SourceColumn: src, Service: "both"}
+		m := RoleMap{Role: role, SourceColumn: src, Slots: map[string]int{}}
 		if serviceCol >= 0 && serviceCol < len(row) {
-			v := strings.TrimSpace(strings.ToLower(row[serviceCol]))
-			if v == "07" {
-				m.Service = "07"
+			m.Services = parseServiceList(row[serviceCol])
+		}
+		for svc, col := range slotsCols {
+			if col < len(row) {
+				if n := atoiSafe(row[col]); n > 0 {
+					m.Slots[svc] = n
+				}
+			}
+		}
+		if priorityCol >= 0 && priorityCol < len(row) {
+			if v := strings.TrimSpace(row[priorityCol]); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 1 {
+					return people, nil, fmt.Errorf("MappingRole baris %d (%s): Priority harus bilangan bulat >= 1, dapat %q", i+1, role, v)
+				}
+				m.Priority = n
 			}
-			if v == "10" {
-				m.Service = "10"
+		}
+		if cooldownCol >= 0 && cooldownCol < len(row) {
+			if v := strings.TrimSpace(row[cooldownCol]); v != "" {
+				n, err := strconv.Atoi(v)
+				if err != nil || n < 0 {
+					return people, nil, fmt.Errorf("MappingRole baris %d (%s): CooldownWeeks harus bilangan bulat >= 0, dapat %q", i+1, role, v)
+				}
+				m.CooldownWeeks = &n
 			}
 		}
-		if slots07Col >= 0 && slots07Col < len(row) {
-			m.Slots07 = atoiSafe(row[slots07Col])
+		if samePersonCol >= 0 && samePersonCol < len(row) && markWeight(row[samePersonCol]) > 0 {
+			if scheduler.IsGroupRole(role) || scheduler.IsMajelisPendamping(role) {
+				return people, nil, fmt.Errorf("MappingRole baris %d (%s): SamePerson hanya untuk role tunggal (bukan Kolektan/P. Jemaat/Lektor/Prokantor/Pemusik/Majelis Pendamping)", i+1, role)
+			}
+			m.SamePerson = true
 		}
-		if slots10Col >= 0 && slots10Col < len(row) {
-			m.Slots10 = atoiSafe(row[slots10Col])
+		if crossCol >= 0 && crossCol < len(row) {
+			rule, err := parseCrossRule(row[crossCol])
+			if err != nil {
+				return people, nil, fmt.Errorf("MappingRole baris %d (%s): %w", i+1, role, err)
+			}
+			m.CrossService = rule
 		}
 		maps = append(maps, m)
 	}
+	if isVerbose() {
+		unused, unknown := orphanColumns(petRows[0], maps)
+		for _, h := range unused {
+			verbosef("WARN: kolom Petugas %q tidak dipakai role mana pun di MappingRole\n", h)
+		}
+		for _, c := range unknown {
+			verbosef("WARN: Kolom Master %q di MappingRole tidak ada di header Petugas\n", c)
+		}
+	}
+	if *requirePenatua {
+		if err := checkPenatua(penatuaCol >= 0, people, maps); err != nil {
+			return people, maps, err
+		}
+	}
 	return people, maps, nil
 }
 
-// ==================== generate() ====================
-
-func generate(assign Assignment, dates []time.Time, people []Person, maps []RoleMap,
-	maxLektor, maxPro, maxMus int, loc *time.Location, verbose bool,
-	kolektanPen, kolektanJem, pjemaatPen, pjemaatJem int) error {
-
-	lastAssigned := map[string]time.Time{}
-
-	// index Penatua untuk rekap cepat
-	penIdx := map[string]bool{}
-	for _, p := range people {
-		penIdx[p.Name] = p.IsPenatua
+// checkPenatua (-requirePenatua): tanpa kolom Penatua, atau tanpa satu pun
+// Penatua sementara ada role Majelis Pendamping, MP tidak akan pernah terisi.
+func checkPenatua(hasCol bool, people []Person, maps []RoleMap) error {
+	if !hasCol {
+		return errors.New("kolom Penatua tidak ada di sheet Petugas (-requirePenatua)")
 	}
-
-	for di, d := range dates {
-		if assign[d] == nil {
-			assign[d] = map[string]map[string][]string{}
+	for _, p := range people {
+		if p.IsPenatua {
+			return nil
 		}
-		services := []string{"07", "10"}
-		assigned07 := map[string]bool{}
-		assigned10 := map[string]bool{}
-		assignedAnyToday := map[string]bool{}
-
-		if verbose {
-			fmt.Printf("=== %s ===\n", d.Format("Mon, 02 Jan 2006"))
+	}
+	for _, m := range maps {
+		if scheduler.IsMajelisPendamping(m.Role) {
+			return fmt.Errorf("tidak ada petugas bertanda Penatua, padahal role %q wajib Penatua (-requirePenatua)", m.Role)
 		}
+	}
+	return nil
+}
 
-		for _, svc := range services {
-			if assign[d][svc] == nil {
-				assign[d][svc] = map[string][]string{}
-			}
-			if verbose {
-				fmt.Printf("  [Service %s]\n", svc)
-			}
-
-			// one-line summary holders untuk komposisi
-			compStatus := map[string]string{"kolektan": "N/A", "pjemaat": "N/A"}
-
-			grouped, others := groupMappingsForService(maps, svc)
-
-			// ---- Split others menjadi MP vs non-MP
-			mpRows := []RoleMap{}
-			otherNonMP := []RoleMap{}
-			for _, m := range others {
-				if m.Service != "both" && m.Service != svc {
-					continue
-				}
-				if isMajelisPendamping(m.Role) {
-					mpRows = append(mpRows, m)
-				} else {
-					otherNonMP = append(otherNonMP, m)
-				}
-			}
-
-			// ---- prefer function (hindari back-to-back Minggu berurutan)
-			var prevSunday time.Time
-			if di > 0 {
-				prevSunday = dates[di-1]
-			}
-			prefer := func(name string) bool {
-				if prevSunday.IsZero() {
-					return true
-				}
-				if t, ok := lastAssigned[name]; ok && sameDay(t, prevSunday) {
-					return false
-				}
-				return true
-			}
-
-			// ======================================================
-			// 1) Majelis Pendamping (prioritas pertama, hanya 10.00)
-			// ======================================================
-			if svc == "10" && len(mpRows) > 0 {
-				for _, m := range mpRows {
-					slots := 1
-					if m.Slots10 > 0 {
-						slots = m.Slots10
-					}
-					cands := filterCandidates(people, m.SourceColumn, true) // wajib Penatua
-					rand.Shuffle(len(cands), func(i, j int) { cands[i], cands[j] = cands[j], cands[i] })
-
-					picked := []string{}
-					// (a) hormati prefer (hindari back-to-back), no double-role 10.00, no multi-role/day
-					for _, name := range cands {
-						if len(picked) >= slots {
-							break
-						}
-						if assigned10[name] || assignedAnyToday[name] {
-							continue
-						}
-						if prefer(name) {
-							picked = append(picked, name)
-							assigned10[name] = true
-							assignedAnyToday[name] = true
-							lastAssigned[name] = d
-						}
-					}
-					// (b) RELAX khusus MP: boleh ambil dari yang sudah bertugas 07.00 hari sama
-					if len(picked) < slots {
-						for _, name := range cands {
-							if len(picked) >= slots {
-								break
-							}
-							if assigned10[name] {
-								continue // tetap jangan dua peran di 10.00
-							}
-							// izinkan meski assignedAnyToday[name] == true (dari 07.00)
-							picked = append(picked, name)
-							assigned10[name] = true
-							assignedAnyToday[name] = true
-							lastAssigned[name] = d
-							if verbose {
-								fmt.Printf("      pick(MP-relax) %-20s\n", name)
-							}
-						}
-					}
-					assign[d][svc][m.Role] = picked
-				}
-			}
-
-			// ======================================================
-			// 2) Komposisi: Kolektan & P. Jemaat (kedua)
-			// ======================================================
-			for _, key := range []string{"kolektan", "pjemaat"} {
-				rows := grouped[key]
-				if len(rows) == 0 {
-					continue
-				}
-				var needPen, needJem int
-				if key == "kolektan" {
-					needPen, needJem = kolektanPen, kolektanJem
-				}
-				if key == "pjemaat" {
-					needPen, needJem = pjemaatPen, pjemaatJem
-				}
-
-				totalNeed := needPen + needJem
-				if totalNeed > len(rows) {
-					totalNeed = len(rows)
-				}
-
-				penNames, jemNames := []string{}, []string{}
-				for _, rm := range rows {
-					p, j := filterCandidatesSplit(people, rm.SourceColumn)
-					penNames = append(penNames, p...)
-					jemNames = append(jemNames, j...)
-				}
-				penNames = uniq(penNames)
-				jemNames = uniq(jemNames)
-				if verbose {
-					fmt.Printf("    %s pool => penatua:%d, jemaat:%d (need P:%d J:%d)\n",
-						key, len(penNames), len(jemaatNames(jemNames)), needPen, needJem)
-				}
-
-				var candPen, candJem []Person
-				for _, n := range penNames {
-					candPen = append(candPen, Person{Name: n, IsPenatua: true})
-				}
-				for _, n := range jemNames {
-					candJem = append(candJem, Person{Name: n, IsPenatua: false})
-				}
-				rand.Shuffle(len(candPen), func(i, j int) { candPen[i], candPen[j] = candPen[j], candPen[i] })
-				rand.Shuffle(len(candJem), func(i, j int) { candJem[i], candJem[j] = candJem[j], candJem[i] })
-
-				var already map[string]bool
-				if svc == "07" {
-					already = assigned07
-				} else {
-					already = assigned10
-				}
-				picked := pickWithComposition(candPen, candJem, needPen, needJem, prefer, already, assignedAnyToday, verbose)
-				if len(picked) > totalNeed {
-					picked = picked[:totalNeed]
-				}
-				for i, rm := range rows {
-					if i < len(picked) {
-						assign[d][svc][rm.Role] = []string{picked[i]}
-						lastAssigned[picked[i]] = d
-					} else {
-						assign[d][svc][rm.Role] = []string{}
-					}
-				}
-
-				// --- Summary per service untuk komposisi (display only)
-				if verbose {
-					// count actual P/J dari picked
-					countP := 0
-					for _, n := range picked {
-						if penIdx[n] {
-							countP++
-						}
-					}
-					countJ := len(picked) - countP
-
-					reqTotal := totalNeed
-					rem := reqTotal
-					reqP := needPen
-					if reqP > rem {
-						reqP = rem
-					}
-					rem -= reqP
-					reqJ := needJem
-					if reqJ > rem {
-						reqJ = rem
-					}
-
-					missingP := 0
-					if countP < reqP {
-						missingP = reqP - countP
-					}
-					missingJ := 0
-					if countJ < reqJ {
-						missingJ = reqJ - countJ
-					}
-					missingSlots := reqTotal - len(picked)
-
-					status := "OK"
-					if missingP > 0 || missingJ > 0 || (*strictCompositionFlag && missingSlots > 0) {
-						status = fmt.Sprintf("KURANG (P:%d J:%d slot:%d)", missingP, missingJ, missingSlots)
-					}
-					fmt.Printf("    Rekap komposisi %s (%s): %s\n", strings.Title(key), svc, status)
-					compStatus[key] = status
-					if *strictCompositionFlag && missingSlots > 0 {
-						fmt.Printf("      (kosong: kuota tidak terpenuhi dengan prefer anti-B2B)\n")
-					}
-				}
-			}
-
-			// ======================================================
-			// 3) Lektor / Prokantor / Pemusik (ketiga)
-			// ======================================================
-			for _, g := range []struct {
-				key   string
-				limit int
-			}{
-				{"lektor", maxLektor}, {"prokantor", maxPro}, {"pemusik", maxMus},
-			} {
-				rows := grouped[g.key]
-				if len(rows) == 0 {
-					continue
-				}
-				limit := g.limit
-				if limit > len(rows) {
-					limit = len(rows)
-				}
-				if verbose {
-					fmt.Printf("    - Group %-10s | Rows: %d | Limit: %d\n", g.key, len(rows), limit)
-				}
-				src := rows[0].SourceColumn
-				names := filterCandidates(people, src, false) // tidak wajib Penatua
-				rand.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
-
-				var already map[string]bool
-				if svc == "07" {
-					already = assigned07
-				} else {
-					already = assigned10
-				}
-
-				picked := []string{}
-				for _, name := range names {
-					if len(picked) >= limit {
-						break
-					}
-					if already[name] || assignedAnyToday[name] {
-						continue
-					}
-					if prefer(name) {
-						picked = append(picked, name)
-						already[name] = true
-						assignedAnyToday[name] = true
-						lastAssigned[name] = d
-						if verbose {
-							fmt.Printf("      pick %-20s\n", name)
-						}
-					}
-				}
-
-				// RELAX phase (fill remaining) -> ONLY if noRelaxB2B is OFF
-				if !*noRelaxB2BFlag && len(picked) < limit {
-					for _, name := range names {
-						if len(picked) >= limit {
-							break
-						}
-						if already[name] || assignedAnyToday[name] {
-							continue
-						}
-						picked = append(picked, name)
-						already[name] = true
-						assignedAnyToday[name] = true
-						lastAssigned[name] = d
-						if verbose {
-							fmt.Printf("      pick(relax) %-12s\n", name)
-						}
-					}
-				}
-
-				for i, rm := range rows {
-					if i < len(picked) {
-						assign[d][svc][rm.Role] = []string{picked[i]}
-					} else {
-						assign[d][svc][rm.Role] = []string{}
-					}
-				}
-			}
-
-			// ======================================================
-			// 4) Role lainnya (non-MP)
-			// ======================================================
-			for _, m := range otherNonMP {
-				if m.Service != "both" && m.Service != svc {
-					continue
-				}
-				if svc == "07" && isMajelisPendamping(m.Role) {
-					continue // safety
-				}
-
-				slots := defaultSlotsForRole(m.Role, svc, maxLektor, maxPro, maxMus)
-				if svc == "07" && m.Slots07 > 0 {
-					slots = m.Slots07
-				}
-				if svc == "10" && m.Slots10 > 0 {
-					slots = m.Slots10
-				}
-
-				cands := filterCandidates(people, m.SourceColumn, isMajelisPendamping(m.Role))
-				rand.Shuffle(len(cands), func(i, j int) { cands[i], cands[j] = cands[j], cands[i] })
-
-				var already map[string]bool
-				if svc == "07" {
-					already = assigned07
-				} else {
-					already = assigned10
-				}
+// parseCrossRule: nilai kolom CrossService; kosong = aturan bawaan.
+func parseCrossRule(v string) (scheduler.CrossRule, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "":
+		return scheduler.CrossDefault, nil
+	case "allow", "boleh", "ya":
+		return scheduler.CrossAllow, nil
+	case "deny", "larang", "tidak":
+		return scheduler.CrossDeny, nil
+	}
+	return scheduler.CrossDefault, fmt.Errorf("CrossService harus allow atau deny, dapat %q", v)
+}
 
-				picked := []string{}
-				for _, name := range cands {
-					if len(picked) >= slots {
-						break
-					}
-					if already[name] || assignedAnyToday[name] {
-						continue
-					}
-					if prefer(name) {
-						picked = append(picked, name)
-						already[name] = true
-						assignedAnyToday[name] = true
-						lastAssigned[name] = d
-					}
-				}
-				// RELAX phase -> ONLY if noRelaxB2B is OFF
-				if !*noRelaxB2BFlag && len(picked) < slots {
-					for _, name := range cands {
-						if len(picked) >= slots {
-							break
-						}
-						if already[name] || assignedAnyToday[name] {
-							continue
-						}
-						picked = append(picked, name)
-						already[name] = true
-						assignedAnyToday[name] = true
-						lastAssigned[name] = d
-					}
-				}
-				assign[d][svc][m.Role] = picked
-			}
+// ==================== generate() ====================
 
-			// One-line summary per service (Kolektan & P. Jemaat)
-			if verbose {
-				fmt.Printf("    Summary %s.00: Kolektan %s | P.Jemaat %s\n", svc, compStatus["kolektan"], compStatus["pjemaat"])
+// generate membungkus scheduler.GenerateSchedule dengan pengaturan dari flag CLI.
+func generate(dates []time.Time, people []Person, maps []RoleMap, prior map[string][]time.Time,
+	maxLektor, maxPro, maxMus int, maxOn map[string]map[string]int, cooldown int, seed int64, kolektan, pjemaat scheduler.Quota,
+	patternOn map[string]patternOverrides, locked, kept Assignment, plan scheduler.SlotPlan, trace *scheduler.Trace, backups Assignment) (Assignment, error) {
+	cfg := scheduler.Config{
+		Dates:             dates,
+		Services:          configuredServiceKeys(),
+		Prior:             prior,
+		MaxLektor:         maxLektor,
+		MaxProkantor:      maxPro,
+		MaxPemusik:        maxMus,
+		MaxOn:             maxOn,
+		CooldownWeeks:     cooldown,
+		CooldownPerRole:   *cooldownPerRoleFlag,
+		MaxPerPerson:      *maxPerPersonFlag,
+		Kolektan:          kolektan,
+		PJemaat:           pjemaat,
+		QuotaOn:           map[string]map[string]scheduler.Quota{},
+		StrictComposition: *strictCompositionFlag,
+		NoRelaxB2B:        *noRelaxB2BFlag,
+		MaxAttempts:       *maxAttemptsFlag,
+		Optimal:           *optimalFlag,
+		Fair:              *fairFlag,
+		Bias:              *biasFlag,
+		StableOrder:       *stableOrderFlag,
+		Seed:              seed,
+		NoSameHousehold:   *noSameHouseholdFlag,
+		NoCrossService:    *noCrossServiceFlag,
+		Pins:              *pinFlag,
+		Locked:            locked,
+		Kept:              kept,
+		Verbose:           isVerbose(),
+		Log:               logWriter(levelNormal),
+		Paint:             colorKind,
+		Plan:              plan,
+		Trace:             trace,
+		Substitutes:       *substitutesFlag,
+		Backups:           backups,
+	}
+	// override pola sudah divalidasi saat flag dibaca
+	for key, po := range patternOn {
+		cfg.QuotaOn[key] = map[string]scheduler.Quota{}
+		for ds, code := range po {
+			q, err := scheduler.QuotaFromPattern(code)
+			if err != nil {
+				return nil, err
 			}
+			cfg.QuotaOn[key][ds] = q
 		}
 	}
-	return nil
+	return scheduler.GenerateSchedule(cfg, people, maps)
 }
 
-// ==================== Grouping & Picker ====================
+// ==================== Services ====================
 
-func groupMappingsForService(maps []RoleMap, svc string) (map[string][]RoleMap, []RoleMap) {
-	groups := map[string][]RoleMap{}
-	var others []RoleMap
-	for _, m := range maps {
-		if m.Service != "both" && m.Service != svc {
-			continue
-		}
-		base := baseRole(m.Role)
-		switch base {
-		case "lektor", "prokantor", "pemusik", "kolektan", "pjemaat":
-			groups[base] = append(groups[base], m)
-		default:
-			others = append(others, m)
+// parseServiceList membaca kolom Service: "07", "10", "07,17", "both"/kosong.
+func parseServiceList(v string) []string {
+	var res []string
+	for _, tok := range strings.FieldsFunc(v, func(r rune) bool { return r == ',' || r == ';' || r == '/' || r == ' ' }) {
+		k := normServiceKey(tok)
+		if k == "" || k == "both" || k == "semua" {
+			return nil
 		}
+		res = append(res, k)
 	}
-	return groups, others
+	return res
 }
 
-func baseRole(role string) string {
-	r := strings.ToLower(strings.TrimSpace(role))
-	if strings.HasPrefix(r, "lektor") {
-		return "lektor"
-	}
-	if strings.HasPrefix(r, "prokantor") {
-		return "prokantor"
-	}
-	if strings.HasPrefix(r, "pemusik") {
-		return "pemusik"
+// normServiceKey: "7", "07.00", "07:00" -> "07"; kunci non-angka apa adanya (lowercase).
+func normServiceKey(s string) string {
+	k := strings.ToLower(strings.TrimSpace(s))
+	for _, suf := range []string{".00", ":00"} {
+		k = strings.TrimSuffix(k, suf)
 	}
-	if strings.HasPrefix(r, "kolektan") {
-		return "kolektan"
+	if n, err := strconv.Atoi(k); err == nil && n >= 0 && n < 24 {
+		return fmt.Sprintf("%02d", n)
 	}
-	if strings.Contains(r, "pjemaat") || strings.Contains(r, "p. jemaat") {
-		return "pjemaat"
-	}
-	return r
+	return k
 }
 
-func pickWithComposition(
-	candPen, candJem []Person,
-	needPen, needJem int,
-	prefer func(string) bool,
-	already map[string]bool,
-	assignedAnyToday map[string]bool,
-	verbose bool,
-) []string {
-	totalNeed := needPen + needJem
-	picked := []string{}
-
-	used := map[string]bool{}
-
-	remaining := func(pool []Person) []Person {
-		res := []Person{}
-		for _, p := range pool {
-			if used[p.Name] || already[p.Name] || assignedAnyToday[p.Name] {
-				continue
-			}
-			res = append(res, p)
-		}
-		return res
-	}
-
-	pickFrom := func(pool []Person, need *int, usePrefer bool, tag string) {
-		for _, p := range pool {
-			if len(picked) >= totalNeed {
-				break
-			}
-			if *need <= 0 {
-				break
-			}
-			if used[p.Name] || already[p.Name] || assignedAnyToday[p.Name] {
-				continue
-			}
-			if usePrefer && !prefer(p.Name) {
-				continue
-			}
-			picked = append(picked, p.Name)
-			used[p.Name] = true
-			already[p.Name] = true
-			assignedAnyToday[p.Name] = true
-			*need--
-			if verbose {
-				if tag != "" {
-					fmt.Printf("      %s %-20s\n", tag, p.Name)
-				} else {
-					fmt.Printf("      pick %-20s\n", p.Name)
-				}
-			}
-		}
-	}
-
-	// Step A: penuhi kuota dengan prefer (anti back-to-back)
-	pickFrom(candPen, &needPen, true, "")
-	pickFrom(candJem, &needJem, true, "")
-
-	// Step B: fallback tetap menjaga kuota per tipe (prefer masih dihormati)
-	if needPen > 0 {
-		pickFrom(remaining(candPen), &needPen, true, "pick(fallback-P)")
+// sortedServices mengembalikan kunci ibadah yang ada pada satu tanggal, terurut
+// (menurut jam bila kunci "services" dikonfigurasi).
+func sortedServices(day map[string]map[string][]string) []string {
+	keys := make([]string, 0, len(day))
+	for k := range day {
+		keys = append(keys, k)
 	}
-	if needJem > 0 {
-		pickFrom(remaining(candJem), &needJem, true, "pick(fallback-J)")
+	sort.Strings(keys)
+	if len(serviceDefs) > 0 {
+		sortByClock(keys)
 	}
+	return keys
+}
 
-	// Step C: relax back-to-back per tipe (abaikan prefer) -> ONLY if noRelaxB2B OFF
-	if !*noRelaxB2BFlag {
-		if needPen > 0 {
-			pickFrom(remaining(candPen), &needPen, false, "pick(relax-P)")
-		}
-		if needJem > 0 {
-			pickFrom(remaining(candJem), &needJem, false, "pick(relax-J)")
+// mappingRoles mengembalikan nama role unik dalam urutan MappingRole.
+func mappingRoles(maps []RoleMap) []string {
+	var roles []string
+	seen := map[string]bool{}
+	for _, m := range maps {
+		if !seen[m.Role] {
+			seen[m.Role] = true
+			roles = append(roles, m.Role)
 		}
 	}
+	return roles
+}
 
-	// Step D: kalau masih belum penuh totalNeed, isi apa saja (hanya jika tidak strict)
-	if !*strictCompositionFlag && len(picked) < totalNeed {
-		merged := append(remaining(candPen), remaining(candJem)...)
-		rand.Shuffle(len(merged), func(i, j int) { merged[i], merged[j] = merged[j], merged[i] })
-		extra := totalNeed - len(picked)
-		pickFrom(merged, &extra, false, "pick(relax-any)")
-	}
-
-	return picked
+// scheduleOf menyusun tampilan terurut assign (plus cadangan -substitutes,
+// boleh nil) untuk writer & exporter: ibadah urut sortedServices, role urut
+// MappingRole.
+func scheduleOf(assign, backups Assignment, dates []time.Time, maps []RoleMap) scheduler.Schedule {
+	return scheduler.NewSchedule(assign, backups, dates, mappingRoles(maps), sortedServices)
 }
 
-func filterCandidatesSplit(people []Person, src string) (penatua []string, jemaat []string) {
-	key := normKey(src)
-	for _, p := range people {
-		if mark, ok := p.Marks[key]; ok && mark {
-			if p.IsPenatua {
-				penatua = append(penatua, p.Name)
-			} else {
-				jemaat = append(jemaat, p.Name)
-			}
-		}
+// backupNote: anotasi " (Cadangan: A, B)" untuk ekspor; kosong tanpa cadangan.
+func backupNote(backups []string) string {
+	if len(backups) == 0 {
+		return ""
 	}
-	return
+	return fmt.Sprintf(" (%s: %s)", tr("Cadangan"), strings.Join(backups, ", "))
+}
+
+// isBackupLine: baris sel "(Cadangan: ...)" tulisan -substitutes, bukan nama
+// petugas; dilewati saat membaca jadwal .xlsx (-diff, -prevSchedule, ...).
+func isBackupLine(s string) bool {
+	return strings.HasPrefix(s, "(Cadangan:") || strings.HasPrefix(s, "(Backup:")
 }
 
 // ==================== Writer ====================
 
-func writeTemplateAware(assign Assignment, maps []RoleMap, dates []time.Time,
-	exeDir, templateFile, outPath string, loc *time.Location, verbose bool) error {
-	cwd, _ := os.Getwd()
-	tplPath := filepath.Join(cwd, templateFile)
-	if _, err := os.Stat(tplPath); err != nil {
-		tplPath = filepath.Join(exeDir, templateFile)
-	}
-	if err := copyFile(tplPath, outPath); err != nil {
+// writeTemplateAware menyalin template ke outPath lalu mengisinya. monthSheet
+// (-combine) tidak kosong: sheet template diklon ke sheet bernama monthSheet
+// yang diisi; template hanya disalin bila outPath belum ada (bulan pertama).
+// tc (boleh nil): template yang sudah dibaca, dipakai bila path-nya sama.
+func writeTemplateAware(assign, backups Assignment, maps []RoleMap, dates []time.Time,
+	exeDir, templateFile, sheetName, outPath, monthSheet string, loc *time.Location, verbose bool, tc *templateCache) error {
+	merge := strings.TrimSpace(*mergeFlag) != ""
+	_, statErr := os.Stat(outPath)
+	tplPath, err := findTemplate(exeDir, templateFile)
+	switch {
+	case merge:
+		tplPath, err = outPath, nil // -merge: outPath = file lama, tidak disalin
+	case monthSheet != "" && statErr == nil:
+		tplPath, err = outPath, nil // -combine: bulan berikutnya ke file yang sama
+	case err == nil && tc != nil && tc.path == tplPath:
+		if err := os.WriteFile(outPath, tc.data, 0o644); err != nil {
+			return err
+		}
+	case err == nil:
+		tc = nil
+		if err := copyFile(tplPath, outPath); err != nil {
+			return err
+		}
+	case *autoTemplate:
+		infof("INFO: %s tidak ditemukan, memakai template bawaan (-autoTemplate)\n", templateFile)
+		if err := writeDefaultTemplate(maps, sheetName, outPath); err != nil {
+			return fmt.Errorf("membuat template bawaan: %w", err)
+		}
+		tplPath = "bawaan"
+	default:
 		return err
 	}
+	debugf("Template: %s -> %s\n", tplPath, outPath)
 	f, err := excelize.OpenFile(outPath)
 	if err != nil {
 		return err
 	}
+	sheet, err := requireSheet(f, sheetName)
+	if err != nil {
+		f.Close() // ditutup dulu agar salinan bisa dihapus (Windows)
+		if !merge {
+			os.Remove(outPath) // jangan tinggalkan salinan template mentah
+		}
+		return fmt.Errorf("template %s: %w", filepath.Base(tplPath), err)
+	}
 	defer f.Close()
-	sheet := "Jadwal Bulanan"
+	if monthSheet != "" {
+		idx, err := f.NewSheet(monthSheet)
+		if err != nil {
+			return err
+		}
+		src, _ := f.GetSheetIndex(sheet)
+		if err := f.CopySheet(src, idx); err != nil {
+			return fmt.Errorf("menyalin sheet %s: %w", sheet, err)
+		}
+		sheet = monthSheet
+	}
+
+	firstCol, err := templateDateColumns()
+	if err != nil {
+		return err
+	}
+	if len(dates) > *dateColsFlag {
+		return fmt.Errorf("%d tanggal melebihi %d kolom tanggal template", len(dates), *dateColsFlag)
+	}
+	// kolom per tanggal: berurutan dari -startColumn, atau lewat header file -merge
+	cols := make([]int, len(dates))
+	for i := range dates {
+		cols[i] = firstCol + i // default B=2
+	}
+	if merge {
+		if cols, err = mergeColumns(f, sheet, dates, loc); err != nil {
+			return err
+		}
+	}
 
 	// --- Fill header placeholders per tanggal (kolom) ---
 	for i, d := range dates {
-		col := 2 + i // B=2
+		col := cols[i]
 		// Cakup header 07.00 & 10.00 (default 30 baris; bisa diubah dengan -headerRows)
 		for r := 1; r <= *headerRowsFlag; r++ {
 			addr := cell(col, r)
 			val, _ := f.GetCellValue(sheet, addr)
 			if strings.Contains(val, "{") {
-				newv := replacePlaceholders(val, d, loc)
+				newv := replacePlaceholders(val, d, loc, labelDatesFlag[d.Format("2006-01-02")])
 				if newv != val {
 					_ = f.SetCellStr(sheet, addr, newv)
 				}
@@ -875,73 +1308,58 @@ func writeTemplateAware(assign Assignment, maps []RoleMap, dates []time.Time,
 		}
 	}
 
-	// --- Hide unused columns (assume 5 slots: B..F) ---
-	totalSlots := 5
-	if len(dates) < totalSlots {
+	// --- Hide unused columns (-dateColumns slot mulai -startColumn, default B..F) ---
+	totalSlots := *dateColsFlag
+	if len(dates) < totalSlots && !merge {
 		for i := len(dates); i < totalSlots; i++ {
-			col := 2 + i
+			col := firstCol + i
 			colName, _ := excelize.ColumnNumberToName(col)
 			_ = f.SetColVisible(sheet, colName, false)
 		}
 	}
 
 	// --- Write assignment values ---
-	for i, d := range dates {
-		col := 2 + i
-		// 07.00
-		for role, vals := range assign[d]["07"] {
-			row := rowForRole(f, sheet, role, true)
-			if row < 1 {
-				if verbose {
-					fmt.Println("WARN: role", role, "tidak ditemukan di template (07.00)")
+	// baris role: dari cache template (sheet hasil salinan/klon template),
+	// selain itu sekali baca dari sheet ini (-merge, template bawaan)
+	var ix *roleRowIndex
+	if tc != nil && !merge {
+		ix = tc.index
+	} else {
+		rows, _ := f.GetRows(sheet)
+		ix = newRoleRowIndex(rows)
+	}
+	merges, _ := f.GetMergeCells(sheet)
+	// urut ibadah lalu MappingRole: WARN & hasil sama di setiap run
+	for i, day := range scheduleOf(assign, backups, dates, maps).Days {
+		d, col := day.Date, cols[i]
+		for _, sv := range day.Services {
+			svc := sv.Service
+			for _, r := range sv.Roles {
+				role, vals := r.Role, r.Names
+				if len(r.Backups) > 0 {
+					// baris terakhir sel; dilewati pembaca .xlsx (isBackupLine)
+					vals = append(vals[:len(vals):len(vals)], strings.TrimSpace(backupNote(r.Backups)))
 				}
-				continue
-			}
-			_ = f.SetCellStr(sheet, cell(col, row), strings.Join(vals, "\n"))
-		}
-		// 10.00
-		for role, vals := range assign[d]["10"] {
-			row := rowForRole(f, sheet, role, false)
-			if row < 1 {
-				if verbose {
-					fmt.Println("WARN: role", role, "tidak ditemukan di template (10.00)")
+				row := ix.row(role, svc)
+				if row < 1 {
+					if verbose {
+						verbosef("WARN: role %s tidak ditemukan di template (%s)\n", role, serviceName(svc, "."))
+					}
+					continue
+				}
+				if !writeNames(f, sheet, cell(col, row), vals, merges, *autoRowHeight) && verbose {
+					verbosef("WARN: %s %s %s: %d nama melebihi tinggi baris (pakai -autoRowHeight)\n",
+						d.Format("2006-01-02"), serviceName(svc, "."), role, len(vals))
 				}
-				continue
 			}
-			_ = f.SetCellStr(sheet, cell(col, row), strings.Join(vals, "\n"))
 		}
 	}
+	stripServiceLabels(f, sheet, ix)
 	return f.Save()
 }
 
-func rowForRole(f *excelize.File, sheet, role string, umum bool) int {
-	rows, _ := f.GetRows(sheet)
-	target := strings.TrimSpace(role)
-	// 1) exact match (case-insensitive)
-	for i, r := range rows {
-		if len(r) > 0 && strings.EqualFold(strings.TrimSpace(r[0]), target) {
-			return i + 1
-		}
-	}
-	// 2) fuzzy khusus Majelis Pendamping
-	if isMajelisPendamping(role) {
-		for i, r := range rows {
-			if len(r) == 0 {
-				continue
-			}
-			lab := strings.ToLower(strings.TrimSpace(r[0]))
-			if strings.Contains(lab, "majel") && strings.Contains(lab, "pend") {
-				return i + 1
-			}
-		}
-	}
-	return -1
-}
-
 // ==================== Utilities ====================
 
-func normKey(s string) string { return strings.ToLower(strings.TrimSpace(s)) }
-
 func exeDir() (string, error) {
 	p, err := os.Executable()
 	if err != nil {
@@ -978,15 +1396,74 @@ func findSheet(f *excelize.File, names []string) string {
 	return ""
 }
 
+// templateDateColumns memvalidasi -dateColumns/-startColumn dan mengembalikan
+// nomor kolom tanggal pertama (B = 2).
+func templateDateColumns() (int, error) {
+	if *dateColsFlag < 1 {
+		return 0, fmt.Errorf("dateColumns minimal 1: %d", *dateColsFlag)
+	}
+	col, err := excelize.ColumnNameToNumber(strings.ToUpper(strings.TrimSpace(*startColFlag)))
+	if err != nil {
+		return 0, fmt.Errorf("startColumn tidak valid: %q", *startColFlag)
+	}
+	if col < 2 {
+		return 0, errors.New("startColumn tidak boleh A (kolom A berisi label role)")
+	}
+	return col, nil
+}
+
+// requireSheet mencari sheet (tanpa beda huruf besar/kecil); bila tidak ada,
+// error menyebutkan sheet yang tersedia.
+func requireSheet(f *excelize.File, name string) (string, error) {
+	if s := findSheet(f, []string{name}); s != "" {
+		return s, nil
+	}
+	return "", fmt.Errorf("sheet %q tidak ditemukan (tersedia: %s)", name, strings.Join(f.GetSheetList(), ", "))
+}
+
+// markWeight: "x"/"ya"/"true" = 1, angka = bobot (0 = tidak eligible), lainnya 0.
+func markWeight(v string) int {
+	if isMarked(v) {
+		return 1
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(v)); err == nil && n > 0 {
+		return n
+	}
+	return 0
+}
+
+// markers: isi sel yang berarti "ya/eligible" (setelah normMarker); -markers menambah daftar.
+var markers = map[string]bool{"x": true, "1": true, "true": true, "ya": true}
+
+// normMarker: huruf kecil, tanpa spasi tepi dan variation selector emoji
+// (mis. "✔️" = "✔" + U+FE0F).
+func normMarker(v string) string {
+	v = strings.Map(func(r rune) rune {
+		if r == '\uFE0E' || r == '\uFE0F' {
+			return -1
+		}
+		return r
+	}, v)
+	return strings.TrimSpace(strings.ToLower(v))
+}
+
+// addMarkers menambah token dari -markers (dipisah koma) ke markers.
+func addMarkers(list string) {
+	for _, tok := range strings.Split(list, ",") {
+		if t := normMarker(tok); t != "" {
+			markers[t] = true
+		}
+	}
+}
+
 func isMarked(v string) bool {
-	vv := strings.TrimSpace(strings.ToLower(v))
-	return vv == "x" || vv == "1" || vv == "true" || vv == "ya"
+	return markers[normMarker(v)]
 }
 
 func indexHeader(head []string) map[string]int {
 	m := map[string]int{}
 	for i, h := range head {
-		m[strings.ToLower(strings.TrimSpace(h))] = i
+		m[scheduler.NormKey(h)] = i
 	}
 	return m
 }
@@ -1011,19 +1488,23 @@ func clamp(v, lo, hi int) int {
 	return v
 }
 
-func mustLoc(name string) *time.Location {
+// loadLoc memuat zona waktu -tz. Kosong atau "Local" = zona sistem. Zona yang
+// tidak dikenal adalah error (bukan diam-diam time.Local); Asia/Jakarta tetap
+// punya cadangan UTC+7 bila tzdata/zoneinfo tidak tersedia.
+func loadLoc(name string) (*time.Location, error) {
+	name = strings.TrimSpace(name)
 	if name == "" {
-		return time.Local
+		return time.Local, nil
 	}
-	if loc, err := time.LoadLocation(name); err == nil && loc != nil {
-		return loc
+	loc, err := time.LoadLocation(name)
+	if err == nil && loc != nil {
+		return loc, nil
 	}
 	// Fallback for Asia/Jakarta if tzdata/zoneinfo is missing
 	if strings.EqualFold(name, "Asia/Jakarta") {
-		return time.FixedZone("WIB", 7*3600) // UTC+7, no DST
+		return time.FixedZone("WIB", 7*3600), nil // UTC+7, no DST
 	}
-	// Last resort: local time (non-nil)
-	return time.Local
+	return nil, fmt.Errorf("zona waktu -tz %q tidak dikenal (contoh: Asia/Jakarta, Asia/Makassar, Asia/Jayapura): %w", name, err)
 }
 
 func safeDate(year, month, day int, loc *time.Location) (time.Time, error) {
@@ -1034,190 +1515,648 @@ func safeDate(year, month, day int, loc *time.Location) (time.Time, error) {
 	return d, nil
 }
 
-func allSundays(year, month int, loc *time.Location) []time.Time {
+// parseDateList membaca "yyyy-mm-dd,yyyy-mm-dd,..." menjadi tanggal unik terurut.
+func parseDateList(s string, loc *time.Location) ([]time.Time, error) {
+	seen := map[string]bool{}
 	var res []time.Time
-	for d := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc); d.Month() == time.Month(month); d = d.AddDate(0, 0, 1) {
-		if d.Weekday() == time.Sunday {
+	for _, tok := range strings.Split(s, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		d, err := time.ParseInLocation("2006-01-02", tok, loc)
+		if err != nil {
+			return nil, fmt.Errorf("tanggal '%s' tidak valid (format yyyy-mm-dd)", tok)
+		}
+		if !seen[tok] {
+			seen[tok] = true
 			res = append(res, d)
 		}
 	}
-	return res
+	if len(res) == 0 {
+		return nil, errors.New("-dates kosong")
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Before(res[j]) })
+	return res, nil
 }
 
-func sameDay(a, b time.Time) bool {
-	ay, am, ad := a.Date()
-	by, bm, bd := b.Date()
-	return ay == by && am == bm && ad == bd
+// skipBatchDates membuang tanggal -skipDates dari daftar tanggal per bulan.
+// Tanggal di luar bulan yang dijadwalkan, atau yang memang tidak ada di
+// daftar (bukan hari ibadah), ditolak agar salah ketik tidak diam-diam lolos.
+func skipBatchDates(batches [][]time.Time, skip []time.Time) ([][]time.Time, error) {
+	drop := map[string]bool{}
+	for _, s := range skip {
+		ds := s.Format("2006-01-02")
+		inMonth := false
+		for _, b := range batches {
+			if len(b) > 0 && b[0].Year() == s.Year() && b[0].Month() == s.Month() {
+				inMonth = true
+			}
+		}
+		if !inMonth {
+			return nil, fmt.Errorf("-skipDates %s di luar bulan yang dijadwalkan", ds)
+		}
+		if !dateInBatches(batches, ds) {
+			return nil, fmt.Errorf("-skipDates %s (%s) tidak ada di daftar tanggal ibadah", ds, dayNames["id"][s.Weekday()])
+		}
+		drop[ds] = true
+	}
+	res := make([][]time.Time, 0, len(batches))
+	for _, b := range batches {
+		var kept []time.Time
+		for _, d := range b {
+			if !drop[d.Format("2006-01-02")] {
+				kept = append(kept, d)
+			}
+		}
+		if len(kept) == 0 {
+			return nil, fmt.Errorf("semua tanggal %s dilewati -skipDates", monthNameID(int(b[0].Month())))
+		}
+		res = append(res, kept)
+	}
+	return res, nil
+}
+
+// allWeekdays: semua tanggal dengan hari wd dalam bulan tsb (default -weekday: Minggu).
+func allWeekdays(year, month int, wd time.Weekday, loc *time.Location) []time.Time {
+	var res []time.Time
+	for d := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, loc); d.Month() == time.Month(month); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() == wd {
+			res = append(res, d)
+		}
+	}
+	return res
 }
 
 func cell(col, row int) string { ref, _ := excelize.CoordinatesToCellName(col, row); return ref }
 
-func filterCandidates(people []Person, src string, mustPenatua bool) []string {
-	key := normKey(src)
-	m := map[string]struct{}{}
+// confirmDates mencetak tanggal (dengan nama hari) lalu membaca y/n dari in.
+// Selain y/ya/yes (termasuk EOF) dianggap batal.
+func confirmDates(batches [][]time.Time, in io.Reader) bool {
+	n := 0
+	for _, ds := range batches {
+		n += len(ds)
+	}
+	fmt.Printf("Tanggal yang akan dijadwalkan (%d):\n", n)
+	for _, ds := range batches {
+		for _, d := range ds {
+			fmt.Printf("  %s, %s\n", dayNameID(d.Weekday()), d.Format("02-01-2006"))
+		}
+	}
+	fmt.Print("Lanjutkan? (y/n): ")
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "ya", "yes":
+		return true
+	}
+	return false
+}
+
+// excludePeople membuang orang yang namanya ada di names (case-insensitive,
+// trim seperti scheduler.NormKey). excluded berisi nama sesuai Master.
+func excludePeople(people []Person, names []string) (kept []Person, excluded, unknown []string) {
+	drop := map[string]bool{}
+	for _, n := range names {
+		drop[scheduler.NormKey(n)] = true
+	}
+	found := map[string]bool{}
 	for _, p := range people {
-		if mustPenatua && !p.IsPenatua {
+		k := scheduler.NormKey(p.Name)
+		if drop[k] {
+			found[k] = true
+			excluded = append(excluded, p.Name)
 			continue
 		}
-		if mark, ok := p.Marks[key]; ok && mark {
-			m[p.Name] = struct{}{}
+		kept = append(kept, p)
+	}
+	for _, n := range names {
+		if !found[scheduler.NormKey(n)] {
+			unknown = append(unknown, n)
 		}
 	}
+	return kept, excluded, unknown
+}
+
+// selectRoles menyaring MappingRole ke role yang role dasarnya (BaseRole)
+// ada di tokens, mis. "Lektor" memilih Lektor 1..4. Token tanpa role = error.
+func selectRoles(maps []RoleMap, tokens []string) ([]RoleMap, map[string]bool, error) {
+	bases := map[string]bool{}
+	for _, t := range tokens {
+		bases[scheduler.BaseRole(t)] = true
+	}
+	var kept []RoleMap
+	hit := map[string]bool{}
+	for _, m := range maps {
+		if b := scheduler.BaseRole(m.Role); bases[b] {
+			hit[b] = true
+			kept = append(kept, m)
+		}
+	}
+	for _, t := range tokens {
+		if !hit[scheduler.BaseRole(t)] {
+			return nil, nil, fmt.Errorf("-roles %q tidak cocok dengan role di MappingRole", t)
+		}
+	}
+	return kept, bases, nil
+}
+
+// parseNameList membaca daftar nama dipisah koma/titik koma (kolom Hindari).
+func parseNameList(s string) []string {
 	var res []string
-	for n := range m {
-		res = append(res, n)
+	for _, tok := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		if n := strings.TrimSpace(tok); n != "" {
+			res = append(res, n)
+		}
 	}
-	sort.Strings(res)
 	return res
 }
 
-func uniq(in []string) []string {
-	m := map[string]struct{}{}
-	var res []string
-	for _, s := range in {
-		if _, ok := m[s]; ok {
+// parseUnavailable membaca daftar tanggal dipisah koma: "dd" (berlaku untuk
+// bulan yang dijadwalkan) atau "yyyy-mm-dd" (juga dd/mm/yyyy).
+func parseUnavailable(s string) (map[string]bool, error) {
+	res := map[string]bool{}
+	for _, tok := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ';' }) {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		if n, err := strconv.Atoi(tok); err == nil {
+			if n < 1 || n > 31 {
+				return nil, fmt.Errorf("tanggal '%s' di luar 1..31", tok)
+			}
+			res[strconv.Itoa(n)] = true
 			continue
 		}
-		m[s] = struct{}{}
-		res = append(res, s)
+		var t time.Time
+		var err error
+		for _, layout := range []string{"2006-01-02", "02/01/2006", "2/1/2006"} {
+			if t, err = time.Parse(layout, tok); err == nil {
+				break
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("tanggal '%s' tidak valid (pakai dd atau yyyy-mm-dd)", tok)
+		}
+		res[t.Format("2006-01-02")] = true
 	}
-	sort.Strings(res)
-	return res
+	return res, nil
 }
 
-// helper to quiet unused var warnings in format string above
-func jemaatNames(in []string) []string { return in }
+// anchorDayOnly mengganti kunci TidakBisa tanggal saja ("7") menjadi
+// yyyy-mm-dd di bulan month (run beberapa bulan), agar tidak berlaku di
+// tanggal yang sama tiap bulan. Mengembalikan nama yang terdampak, urut.
+func anchorDayOnly(people []Person, year int, month time.Month, loc *time.Location) []string {
+	var names []string
+	for _, p := range people {
+		hit := false
+		for k := range p.Unavailable {
+			n, err := strconv.Atoi(k)
+			if err != nil {
+				continue
+			}
+			delete(p.Unavailable, k)
+			hit = true
+			if d := time.Date(year, month, n, 0, 0, 0, 0, loc); d.Month() == month {
+				p.Unavailable[d.Format("2006-01-02")] = true
+			}
+		}
+		if hit {
+			names = append(names, p.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Singkatan/ejaan lama yang bukan awalan nama bulan.
+var monthAliases = map[string]int{
+	"agt": 8, "ags": 8, "peb": 2, "pebruari": 2, "nop": 11, "nopember": 11,
+}
 
+// parseMonth: 1-12, nama bulan bahasa apa pun di monthNames (header output
+// -lang en juga terbaca), awalan >= 3 huruf ("Agu", "Sept", "Des", "Aug"),
+// alias di monthAliases, atau salah ketik 1 huruf untuk input >= 5 huruf.
+// Hanya diterima bila semua kecocokan menunjuk bulan yang sama.
 func parseMonth(s string) (int, error) {
-	m := map[string]int{"januari": 1, "februari": 2, "maret": 3, "april": 4, "mei": 5, "juni": 6, "juli": 7, "agustus": 8, "september": 9, "oktober": 10, "november": 11, "desember": 12}
-	if n, ok := m[strings.ToLower(strings.TrimSpace(s))]; ok {
-		return n, nil
-	}
+	key := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), ".")
 	var x int
-	if _, err := fmt.Sscanf(s, "%d", &x); err == nil && x >= 1 && x <= 12 {
+	if _, err := fmt.Sscanf(key, "%d", &x); err == nil && x >= 1 && x <= 12 {
 		return x, nil
 	}
-	return 0, fmt.Errorf("bulan tidak valid: %s", s)
-}
-func monthNameID(m int) string {
-	names := []string{"", "Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
-	if m >= 1 && m <= 12 {
-		return names[m]
-	}
-	return "?"
-}
-
-// New: day name (ID)
-func dayNameID(wd time.Weekday) string {
-	switch wd {
-	case time.Monday:
-		return "Senin"
-	case time.Tuesday:
-		return "Selasa"
-	case time.Wednesday:
-		return "Rabu"
-	case time.Thursday:
-		return "Kamis"
-	case time.Friday:
-		return "Jumat"
-	case time.Saturday:
-		return "Sabtu"
-	default:
-		return "Minggu"
+	if m, ok := monthAliases[key]; ok {
+		return m, nil
+	}
+	match := func(ok func(name string) bool) int {
+		found := 0
+		for _, names := range monthNames {
+			for i := 1; i < len(names); i++ {
+				if !ok(strings.ToLower(names[i])) {
+					continue
+				}
+				if found != 0 && found != i {
+					return -1 // ambigu
+				}
+				found = i
+			}
+		}
+		return found
+	}
+	if m := match(func(n string) bool { return n == key }); m > 0 {
+		return m, nil
+	}
+	if len(key) >= 3 {
+		if m := match(func(n string) bool { return strings.HasPrefix(n, key) }); m > 0 {
+			return m, nil
+		}
+	}
+	if len(key) >= 5 {
+		if m := match(func(n string) bool { return editDistance1(n, key) }); m > 0 {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("bulan tidak valid: %s (contoh: Agustus, Agt, August, 8)", s)
+}
+
+// editDistance1: a dan b berbeda tepat satu sisip/hapus/ganti atau satu
+// tukar huruf bersebelahan.
+func editDistance1(a, b string) bool {
+	if a == b {
+		return false
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 {
+		return false
+	}
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) == len(b) {
+		if a[i+1:] == b[i+1:] {
+			return true // ganti satu huruf
+		}
+		return i+1 < len(a) && a[i] == b[i+1] && a[i+1] == b[i] && a[i+2:] == b[i+2:] // tukar
+	}
+	return a[i:] == b[i+1:] // sisip/hapus satu huruf
+}
+
+// parseWeekday: 0-6 (0 = Minggu) atau nama hari bahasa apa pun di dayNames.
+func parseWeekday(s string) (time.Weekday, error) {
+	key := strings.ToLower(strings.TrimSpace(s))
+	for _, names := range dayNames {
+		for i, n := range names {
+			if strings.ToLower(n) == key {
+				return time.Weekday(i), nil
+			}
+		}
+	}
+	if n, err := strconv.Atoi(key); err == nil && n >= 0 && n <= 6 {
+		return time.Weekday(n), nil
+	}
+	return 0, fmt.Errorf("hari tidak valid: %s (pakai nama hari atau 0-6, 0=Minggu)", s)
+}
+
+// parseMonthList membaca -months: rentang "8-12", daftar "8,10,12", atau
+// campuran ("1-3,8"); nama bulan juga boleh. Hasil unik & terurut.
+func parseMonthList(s string) ([]int, error) {
+	one := func(tok string) (int, error) {
+		tok = strings.TrimSpace(tok)
+		if n, err := strconv.Atoi(tok); err == nil {
+			if n < 1 || n > 12 {
+				return 0, fmt.Errorf("bulan di luar 1-12: %q", tok)
+			}
+			return n, nil
+		}
+		if tok == "" || strings.ContainsAny(tok, "0123456789") {
+			return 0, fmt.Errorf("bulan tidak valid: %q", tok)
+		}
+		return parseMonth(tok)
+	}
+	seen := map[int]bool{}
+	var res []int
+	for _, tok := range strings.Split(s, ",") {
+		lo, hi := tok, tok
+		if i := strings.Index(tok, "-"); i >= 0 {
+			lo, hi = tok[:i], tok[i+1:]
+		}
+		a, err := one(lo)
+		if err != nil {
+			return nil, fmt.Errorf("-months %q: %w", s, err)
+		}
+		b, err := one(hi)
+		if err != nil {
+			return nil, fmt.Errorf("-months %q: %w", s, err)
+		}
+		if a > b {
+			return nil, fmt.Errorf("-months %q: rentang terbalik %q", s, strings.TrimSpace(tok))
+		}
+		for m := a; m <= b; m++ {
+			if !seen[m] {
+				seen[m] = true
+				res = append(res, m)
+			}
+		}
 	}
+	sort.Ints(res)
+	return res, nil
+}
+
+const defaultOutName = "JadwalPetugas_{month}_{timestamp}"
+
+// expandOutName mengisi token pola -outName dan mengembalikan nama dasar
+// tanpa ekstensi (.xlsx/.json/.ics/... ditambahkan oleh penulis masing-masing).
+func expandOutName(pattern string, month, year int, now time.Time) (string, error) {
+	r := strings.NewReplacer(
+		"{month}", monthNameID(month),
+		"{mm}", fmt.Sprintf("%02d", month),
+		"{year}", fmt.Sprintf("%04d", year),
+		"{date}", now.Format("2006-01-02"),
+		"{timestamp}", fmt.Sprintf("%02d.%02d.%02d", now.Hour(), now.Minute(), now.Second()),
+	)
+	name := r.Replace(pattern)
+	if i := strings.Index(name, "{"); i >= 0 && strings.Contains(name[i:], "}") {
+		return "", fmt.Errorf("-outName: token tidak dikenal di %q (pakai {month} {mm} {year} {date} {timestamp})", pattern)
+	}
+	if strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("-outName tidak boleh berisi folder: %q (pakai -outdir)", pattern)
+	}
+	// ekstensi output ditulis terpisah; buang bila pengguna sudah menuliskannya
+	for _, ext := range []string{".xlsx", ".json"} {
+		if strings.HasSuffix(strings.ToLower(name), ext) {
+			name = name[:len(name)-len(ext)]
+			break
+		}
+	}
+	if strings.TrimSpace(name) == "" {
+		return "", fmt.Errorf("-outName menghasilkan nama kosong: %q", pattern)
+	}
+	return name, nil
+}
+
+// expandOutNameRange (-combine): {month} dan {mm} menjadi rentang,
+// mis. "Juli-September" dan "07-09".
+func expandOutNameRange(pattern string, first, last, year int, now time.Time) (string, error) {
+	if first != last {
+		pattern = strings.NewReplacer(
+			"{month}", monthNameID(first)+"-"+monthNameID(last),
+			"{mm}", fmt.Sprintf("%02d-%02d", first, last),
+		).Replace(pattern)
+	}
+	return expandOutName(pattern, first, year, now)
 }
 
 // New: placeholder replacer
-func replacePlaceholders(s string, d time.Time, loc *time.Location) string {
-	day := dayNameID(d.Weekday())
-	dd := fmt.Sprintf("%02d", d.Day())
+func replacePlaceholders(s string, d time.Time, loc *time.Location, label string) string {
+	// token berkurung kurawal tidak saling memuat ({d} vs {dd}, {MMM} vs
+	// {MMMM}), jadi urutan penggantian tidak berpengaruh; nama sesuai -lang.
+	// {MMM} tetap nama bulan lengkap seperti sebelumnya (template lama)
 	mon := monthNameID(int(d.Month()))
-	yyyy := fmt.Sprintf("%04d", d.Year())
-	out := s
-	out = strings.ReplaceAll(out, "{Day}", day)
-	out = strings.ReplaceAll(out, "{dd}", dd)
-	// treat {MMM} and {MMMM} as full month name in ID
-	out = strings.ReplaceAll(out, "{MMM}", mon)
-	out = strings.ReplaceAll(out, "{MMMM}", mon)
-	out = strings.ReplaceAll(out, "{yyyy}", yyyy)
-	return out
+	out := strings.NewReplacer(
+		"{Day}", dayNameID(d.Weekday()),
+		"{DayShort}", shortName(dayNameID(d.Weekday())),
+		"{dd}", fmt.Sprintf("%02d", d.Day()),
+		"{d}", strconv.Itoa(d.Day()),
+		"{MMMM}", mon,
+		"{MMM}", mon,
+		"{MonShort}", shortName(mon),
+		"{m}", strconv.Itoa(int(d.Month())),
+		"{yyyy}", fmt.Sprintf("%04d", d.Year()),
+		"{yy}", fmt.Sprintf("%02d", d.Year()%100),
+	).Replace(s)
+	if !strings.Contains(out, "{Label}") {
+		return out
+	}
+	if label != "" {
+		return strings.ReplaceAll(out, "{Label}", label)
+	}
+	// tanggal tanpa label: hanya baris yang memuat {Label} dirapikan
+	// (spasi di tepi dibuang, baris yang jadi kosong dihapus); baris lain utuh
+	var lines []string
+	for _, l := range strings.Split(out, "\n") {
+		if strings.Contains(l, "{Label}") {
+			if l = strings.TrimSpace(strings.ReplaceAll(l, "{Label}", "")); l == "" {
+				continue
+			}
+		}
+		lines = append(lines, l)
+	}
+	return strings.Join(lines, "\n")
 }
 
 // ==================== Pattern & Role Helpers ====================
 
-func parsePattern(code string) (penatua, jemaat, total int, err error) {
-	c := strings.ToLower(strings.TrimSpace(code))
-	if len(c) < 2 {
-		return 0, 0, 0, fmt.Errorf("kode '%s' tidak valid", code)
-	}
-	var n int
-	var suf string
-	if _, e := fmt.Sscanf(c, "%d%s", &n, &suf); e != nil {
-		return 0, 0, 0, fmt.Errorf("kode '%s' tidak valid", code)
-	}
-	if n < 1 || n > 4 {
-		return 0, 0, 0, fmt.Errorf("jumlah '%d' di luar batas 1..4", n)
-	}
-	switch n {
-	case 1:
-		if suf == "a" {
-			return 1, 0, 1, nil
-		}
-		if suf == "b" {
-			return 0, 1, 1, nil
-		}
-	case 2:
-		switch suf {
-		case "a":
-			return 1, 1, 2, nil
-		case "b":
-			return 2, 0, 2, nil
-		case "c":
-			return 0, 2, 2, nil
-		}
-	case 3:
-		switch suf {
-		case "a":
-			return 1, 2, 3, nil
-		case "b":
-			return 2, 1, 3, nil
-		case "c":
-			return 3, 0, 3, nil
-		case "d":
-			return 0, 3, 3, nil
-		}
-	case 4:
-		switch suf {
-		case "a":
-			return 1, 3, 4, nil
-		case "b":
-			return 2, 2, 4, nil
-		case "c":
-			return 3, 1, 4, nil
-		case "d":
-			return 4, 0, 4, nil
-		case "e":
-			return 0, 4, 4, nil
-		}
-	}
-	return 0, 0, 0, fmt.Errorf("kode '%s' tidak dikenali", code)
-}
-
-func isMajelisPendamping(role string) bool {
-	r := strings.ToLower(role)
-	return strings.Contains(r, "majel") && strings.Contains(r, "pend")
-}
-
-func defaultSlotsForRole(role, svc string, maxLektor, maxPro, maxMus int) int {
-	low := strings.ToLower(strings.TrimSpace(role))
-	if strings.Contains(low, "lektor") {
-		return maxLektor
-	}
-	if strings.Contains(low, "prokantor") {
-		return maxPro
-	}
-	if strings.Contains(low, "pemusik") {
-		return maxMus
-	}
-	return 1
+// patternOverrides: tanggal "yyyy-mm-dd" -> kode pola, diisi flag berulang
+// -kolektanPatternOn / -pjemaatPatternOn ("2025-09-07=3b", boleh dipisah koma).
+type patternOverrides map[string]string
+
+func patternOnFlag(name, usage string) patternOverrides {
+	po := patternOverrides{}
+	flag.Var(po, name, usage)
+	return po
+}
+
+func (po patternOverrides) String() string {
+	keys := make([]string, 0, len(po))
+	for k := range po {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+po[k])
+	}
+	return strings.Join(parts, ",")
+}
+
+func (po patternOverrides) Set(v string) error {
+	for _, tok := range strings.Split(v, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		ds, code, ok := strings.Cut(tok, "=")
+		if !ok {
+			return fmt.Errorf("format harus yyyy-mm-dd=kode: %q", tok)
+		}
+		d, err := time.Parse("2006-01-02", strings.TrimSpace(ds))
+		if err != nil {
+			return fmt.Errorf("tanggal tidak valid: %q", ds)
+		}
+		code = strings.ToLower(strings.TrimSpace(code))
+		if _, _, _, err := scheduler.ParsePattern(code); err != nil {
+			return err
+		}
+		po[d.Format("2006-01-02")] = code
+	}
+	return nil
+}
+
+// dateLabels: tanggal "yyyy-mm-dd" -> label untuk {Label}, dari -labelDates
+// berulang ("2025-09-07=Perjamuan Kudus"). Satu tanggal per flag, jadi label
+// boleh berisi koma.
+type dateLabels map[string]string
+
+func dateLabelsFlag(name, usage string) dateLabels {
+	dl := dateLabels{}
+	flag.Var(dl, name, usage)
+	return dl
+}
+
+func (dl dateLabels) String() string {
+	return strings.Join(dl.items(), ",")
+}
+
+// items: satu "yyyy-mm-dd=label" per tanggal, urut tanggal (bentuk yang diterima Set).
+func (dl dateLabels) items() []string {
+	keys := make([]string, 0, len(dl))
+	for k := range dl {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+dl[k])
+	}
+	return parts
+}
+
+func (dl dateLabels) Set(v string) error {
+	ds, label, ok := strings.Cut(v, "=")
+	if !ok {
+		return fmt.Errorf("format harus yyyy-mm-dd=label: %q", v)
+	}
+	d, err := time.Parse("2006-01-02", strings.TrimSpace(ds))
+	if err != nil {
+		return fmt.Errorf("tanggal tidak valid: %q", ds)
+	}
+	dl[d.Format("2006-01-02")] = strings.TrimSpace(label)
+	return nil
+}
+
+// serviceMax: service -> batas, diisi -maxLektorService dst ("07=1,10=2";
+// "07:1" juga diterima). Kunci service dinormalkan seperti kolom Service.
+type serviceMax map[string]int
+
+func serviceMaxFlag(name, usage string) serviceMax {
+	sm := serviceMax{}
+	flag.Var(sm, name, usage)
+	return sm
+}
+
+func (sm serviceMax) String() string {
+	keys := make([]string, 0, len(sm))
+	for k := range sm {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s=%d", k, sm[k]))
+	}
+	return strings.Join(parts, ",")
+}
+
+func (sm serviceMax) Set(v string) error {
+	for _, tok := range strings.Split(v, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		svc, num, ok := strings.Cut(tok, "=")
+		if !ok {
+			i := strings.LastIndex(tok, ":")
+			if i < 0 {
+				return fmt.Errorf("format harus service=jumlah: %q", tok)
+			}
+			svc, num = tok[:i], tok[i+1:]
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(num))
+		if err != nil || n < 1 {
+			return fmt.Errorf("jumlah tidak valid: %q", tok)
+		}
+		key := normServiceKey(svc)
+		if key == "" {
+			return fmt.Errorf("service kosong: %q", tok)
+		}
+		sm[key] = n
+	}
+	return nil
+}
+
+// clamped: salinan dengan setiap nilai dibatasi lo..hi seperti -maxLektor dst.
+func (sm serviceMax) clamped(lo, hi int) map[string]int {
+	out := make(map[string]int, len(sm))
+	for k, n := range sm {
+		out[k] = clamp(n, lo, hi)
+	}
+	return out
+}
+
+// pinList: penugasan tetap dari -pin berulang ("2025-09-07:10:Lektor=Budi").
+type pinList []scheduler.Pin
+
+func pinListFlag(name, usage string) *pinList {
+	pl := &pinList{}
+	flag.Var(pl, name, usage)
+	return pl
+}
+
+func (pl *pinList) String() string {
+	return strings.Join(pl.items(), ",")
+}
+
+// items: satu "yyyy-mm-dd:service:Role=Nama" per pin (bentuk yang diterima Set).
+func (pl *pinList) items() []string {
+	parts := make([]string, 0, len(*pl))
+	for _, p := range *pl {
+		parts = append(parts, fmt.Sprintf("%s:%s:%s=%s", p.Date, p.Service, p.Role, p.Name))
+	}
+	return parts
+}
+
+func (pl *pinList) Set(v string) error {
+	slot, name, ok := strings.Cut(v, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" {
+		return fmt.Errorf("format harus yyyy-mm-dd:service:Role=Nama: %q", v)
+	}
+	ds, rest, ok := strings.Cut(strings.TrimSpace(slot), ":")
+	if !ok {
+		return fmt.Errorf("format harus yyyy-mm-dd:service:Role=Nama: %q", v)
+	}
+	d, err := time.Parse("2006-01-02", strings.TrimSpace(ds))
+	if err != nil {
+		return fmt.Errorf("tanggal tidak valid: %q", ds)
+	}
+	svc, role, ok := strings.Cut(rest, ":")
+	role = strings.TrimSpace(role)
+	if !ok || role == "" || strings.TrimSpace(svc) == "" {
+		return fmt.Errorf("format harus yyyy-mm-dd:service:Role=Nama: %q", v)
+	}
+	*pl = append(*pl, scheduler.Pin{Date: d.Format("2006-01-02"), Service: normServiceKey(svc), Role: role, Name: name})
+	return nil
+}
+
+// pinnedAt: name di-pin pada tanggal d ibadah svc (rebalance tidak boleh menggantinya).
+func (pl pinList) pinnedAt(d time.Time, svc, name string) bool {
+	ds := d.Format("2006-01-02")
+	for _, p := range pl {
+		if p.Date == ds && p.Service == svc && scheduler.NormKey(p.Name) == scheduler.NormKey(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func dateInBatches(batches [][]time.Time, ds string) bool {
+	for _, dates := range batches {
+		for _, d := range dates {
+			if d.Format("2006-01-02") == ds {
+				return true
+			}
+		}
+	}
+	return false
 }
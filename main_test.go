@@ -0,0 +1,348 @@
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// Nilai sel kolom Petugas -> eligible/bobot, seperti dibaca loadMaster.
+func TestMarkEligibility(t *testing.T) {
+	cases := []struct {
+		cell     string
+		col      string // kolom yang dicari (bisa tidak dikenal)
+		eligible bool
+		weight   int
+	}{
+		{"x", "Lektor", true, 1},
+		{"X", "Lektor", true, 1},
+		{"YA", "Lektor", true, 1},
+		{"ya ", "Lektor", true, 1},
+		{"1", "Lektor", true, 1},
+		{"true", "Lektor", true, 1},
+		{"3", "Lektor", true, 3},
+		{"", "Lektor", false, 0},
+		{"0", "Lektor", false, 0},
+		{"tidak", "Lektor", false, 0},
+		{"x", "Kolom Lain", false, 0},
+	}
+	for _, tc := range cases {
+		w := markWeight(tc.cell)
+		key := scheduler.NormKey("Lektor")
+		p := Person{Name: "A", Marks: map[string]bool{key: w > 0}, Weights: map[string]int{key: w}}
+
+		pen, jem := scheduler.EligibleFor([]Person{p}, scheduler.NormKey(tc.col), time.Time{})
+		if len(pen) != 0 {
+			t.Fatalf("sel %q: Jemaat tidak boleh masuk daftar Penatua", tc.cell)
+		}
+		if got := len(jem) == 1; got != tc.eligible {
+			t.Errorf("sel %q kolom %q: eligible=%v, ingin %v", tc.cell, tc.col, got, tc.eligible)
+			continue
+		}
+		if tc.eligible && jem[0].Weight != tc.weight {
+			t.Errorf("sel %q: bobot %d, ingin %d", tc.cell, jem[0].Weight, tc.weight)
+		}
+		if got := len(scheduler.FilterCandidates([]Person{p}, tc.col, false, time.Time{})) == 1; got != tc.eligible {
+			t.Errorf("sel %q kolom %q: FilterCandidates eligible=%v, ingin %v", tc.cell, tc.col, got, tc.eligible)
+		}
+	}
+}
+
+func TestParseMonth(t *testing.T) {
+	cases := []struct {
+		in   string
+		want int // 0 = error
+	}{
+		{"Agustus", 8},
+		{" agustus ", 8},
+		{"8", 8},
+		{"12", 12},
+		{"August", 8},
+		{"Agt", 8},
+		{"agu", 8},
+		{"Aug.", 8},
+		{"Sep", 9},
+		{"Sept", 9},
+		{"Des", 12},
+		{"dec", 12},
+		{"Okt", 10},
+		{"Mei", 5},
+		{"may", 5},
+		{"Mar", 3},
+		{"Jun", 6},
+		{"Pebruari", 2},
+		{"Nop", 11},
+		{"Agutsus", 8},    // tukar huruf
+		{"Febuari", 2},    // kurang satu huruf
+		{"Septemberr", 9}, // lebih satu huruf
+		{"", 0},
+		{"13", 0},
+		{"ju", 0},
+		{"Bulan", 0},
+		{"Agstss", 0},
+	}
+	for _, tc := range cases {
+		got, err := parseMonth(tc.in)
+		if tc.want == 0 {
+			if err == nil {
+				t.Errorf("parseMonth(%q) = %d, ingin error", tc.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tc.want {
+			t.Errorf("parseMonth(%q) = %d, %v; ingin %d", tc.in, got, err, tc.want)
+		}
+	}
+}
+
+func TestCustomMarkers(t *testing.T) {
+	saved := markers
+	defer func() { markers = saved }()
+	markers = map[string]bool{}
+	for k := range saved {
+		markers[k] = true
+	}
+
+	addMarkers(" V, ✓ ,Bisa,")
+	for _, cell := range []string{"v", "V", "✓", " ✓ ", "bisa", "BISA", "x", "ya"} {
+		if !isMarked(cell) {
+			t.Errorf("sel %q: ingin eligible", cell)
+		}
+	}
+	for _, cell := range []string{"✔", "", "tidak", "vv"} {
+		if isMarked(cell) {
+			t.Errorf("sel %q: tidak boleh eligible", cell)
+		}
+	}
+	addMarkers("✔")
+	if !isMarked("✔️") {
+		t.Error("✔ + variation selector harus cocok dengan ✔")
+	}
+}
+
+func TestMergeDuplicateNames(t *testing.T) {
+	people := []Person{
+		{Name: "Budi Santoso", Marks: map[string]bool{"lektor": true}, Weights: map[string]int{"lektor": 1}},
+		{Name: "Ani", Partner: "budi  santoso", Marks: map[string]bool{}, Weights: map[string]int{}},
+		{Name: "budi santoso", IsPenatua: true, Avoid: []string{"ani "},
+			Marks: map[string]bool{"lektor": false, "pemusik": true}, Weights: map[string]int{"lektor": 0, "pemusik": 2}},
+	}
+	if g := nearDuplicateNames(people, []int{2, 3, 4}); len(g) != 1 || len(g[0].Spelling) != 2 || g[0].Rows[1] != 4 {
+		t.Fatalf("nearDuplicateNames = %+v, ingin 1 kelompok berisi 2 nama", g)
+	}
+	got := mergeDuplicateNames(people)
+	if len(got) != 2 {
+		t.Fatalf("jumlah orang %d, ingin 2", len(got))
+	}
+	b := got[0]
+	if b.Name != "Budi Santoso" || !b.IsPenatua || !b.Marks["lektor"] || !b.Marks["pemusik"] || b.Weights["pemusik"] != 2 || b.Weights["lektor"] != 1 {
+		t.Errorf("hasil gabung salah: %+v", b)
+	}
+	if len(b.Avoid) != 1 || b.Avoid[0] != "Ani" {
+		t.Errorf("Hindari %v, ingin [Ani]", b.Avoid)
+	}
+	if got[1].Partner != "Budi Santoso" {
+		t.Errorf("Pasangan %q, ingin penulisan kanonik", got[1].Partner)
+	}
+}
+
+// Kunci config salah ketik harus ditolak dengan nomor baris & saran ejaan.
+func TestCheckConfigKeys(t *testing.T) {
+	cases := []struct {
+		ext, src string
+		line     int
+	}{
+		{".json", "{\n  \"bulan\": 8,\n  \"maxLektr\": 3\n}\n", 3},
+		{".yaml", "bulan: 8\nmaxLektr: 3\n", 2},
+	}
+	for _, tc := range cases {
+		lines := configKeyLines([]byte(tc.src), tc.ext)
+		if lines["maxLektr"] != tc.line {
+			t.Errorf("%s: baris maxLektr = %d, ingin %d", tc.ext, lines["maxLektr"], tc.line)
+		}
+		err := checkConfigKeys(map[string]interface{}{"bulan": 8, "maxLektr": 3}, lines)
+		if err == nil || !strings.Contains(err.Error(), `"maxLektr" (baris`) || !strings.Contains(err.Error(), `maksudnya "maxLektor"`) {
+			t.Errorf("%s: error = %v", tc.ext, err)
+		}
+	}
+	if err := checkConfigKeys(map[string]interface{}{"bulan": 8, "services": nil}, nil); err != nil {
+		t.Errorf("kunci valid ditolak: %v", err)
+	}
+}
+
+// Reader .ods: sel/baris berulang diperluas, ujung kosong dibuang, komentar sel diabaikan.
+func TestReadODS(t *testing.T) {
+	content := `<?xml version="1.0" encoding="UTF-8"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+ xmlns:table="urn:oasis:names:tc:opendocument:xmlns:table:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0">
+<office:body><office:spreadsheet><table:table table:name="Petugas">
+<table:table-row><table:table-cell><text:p>Nama</text:p></table:table-cell><table:table-cell table:number-columns-repeated="2"><text:p>x</text:p></table:table-cell></table:table-row>
+<table:table-row table:number-rows-repeated="2"><table:table-cell table:number-columns-repeated="5"/></table:table-row>
+<table:table-row><table:table-cell><office:annotation><text:p>catatan</text:p></office:annotation><text:p>Budi<text:s text:c="2"/>S</text:p><text:p>baris 2</text:p></table:table-cell><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+<table:table-row table:number-rows-repeated="1048000"><table:table-cell table:number-columns-repeated="1024"/></table:table-row>
+</table:table></office:spreadsheet></office:body></office:document-content>`
+	path := filepath.Join(t.TempDir(), "m.ods")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("content.xml")
+	w.Write([]byte(content))
+	zw.Close()
+	f.Close()
+
+	sheets, err := readODS(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"Nama", "x", "x"}, nil, nil, {"Budi  S\nbaris 2"}}
+	if got := sheets["Petugas"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("Petugas = %q, ingin %q", got, want)
+	}
+}
+
+// Header Petugas dengan BOM/NBSP/zero-width tetap cocok dengan Kolom Master di MappingRole.
+func TestLoadMasterOddHeaders(t *testing.T) {
+	saved := logLvl
+	logLvl = levelQuiet
+	defer func() { logLvl = saved }()
+
+	dir := t.TempDir()
+	petugas := "\ufeffNama,Penatua,P.\u00a0Jemaat,Lek\u200btor ,Pemusik\n" +
+		"Budi,x,x,x,\n" +
+		"Ani,,x,,x\n"
+	mapping := "Role,Kolom Master\nP. Jemaat 1,P. Jemaat\nLektor 1,Lektor\nPemusik 1,\u00a0Pemusik\u200b\n"
+	if err := os.WriteFile(filepath.Join(dir, "Petugas.csv"), []byte(petugas), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "MappingRole.csv"), []byte(mapping), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	people, maps, err := loadMaster(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"P. Jemaat 1": {"Ani", "Budi"}, "Lektor 1": {"Budi"}, "Pemusik 1": {"Ani"}}
+	for _, m := range maps {
+		got := scheduler.FilterCandidates(people, m.SourceColumn, false, time.Time{})
+		if !reflect.DeepEqual(got, want[m.Role]) {
+			t.Errorf("%s (kolom %q): eligible %v, ingin %v", m.Role, m.SourceColumn, got, want[m.Role])
+		}
+	}
+}
+
+// -color: auto mati di luar terminal & dengan NO_COLOR; baris log scheduler
+// diwarnai menurut isi, newline tetap di luar kode warna.
+func TestColor(t *testing.T) {
+	defer func() { colorOn = false }()
+	t.Setenv("NO_COLOR", "1")
+	if err := initColor("auto"); err != nil || colorOn {
+		t.Fatalf("auto dengan NO_COLOR: on=%v err=%v", colorOn, err)
+	}
+	if err := initColor("pelangi"); err == nil {
+		t.Fatal("mode tidak dikenal harus error")
+	}
+	if err := initColor("always"); err != nil || !colorOn {
+		t.Fatalf("always: on=%v err=%v", colorOn, err)
+	}
+
+	// warna dari jenis baris, bukan teks (pesan terjemahan tetap diwarnai)
+	cases := []struct {
+		kind scheduler.LogKind
+		in   string
+		want string
+	}{
+		{scheduler.LogPick, "      pick Budi\n", ansiGreen + "      pick Budi" + ansiReset + "\n"},
+		{scheduler.LogRelax, "      pick(relax) Ani\n", ansiYellow + "      pick(relax) Ani" + ansiReset + "\n"},
+		{scheduler.LogWarn, "WARNING: composition", ansiYellow + "WARNING: composition" + ansiReset},
+		{scheduler.LogEmpty, "      (empty: quota)\n", ansiRed + "      (empty: quota)" + ansiReset + "\n"},
+		{scheduler.LogPlain, "      pick kosong relax)\n", "      pick kosong relax)\n"},
+	}
+	for _, c := range cases {
+		if got := colorKind(c.kind, c.in); got != c.want {
+			t.Errorf("colorKind(%d, %q) = %q, ingin %q", c.kind, c.in, got, c.want)
+		}
+	}
+	colorOn = false
+	if got := colorKind(scheduler.LogWarn, "WARN: x\n"); got != "WARN: x\n" {
+		t.Errorf("tanpa -color: %q", got)
+	}
+}
+
+// -rebalance menukar cadangan ke slot utama: cadangan itu dibuang dari
+// tanggal tempat ia kini bertugas, tanggal lain tetap.
+func TestRebalancePrunesBackups(t *testing.T) {
+	lektor := map[string]bool{"lektor": true}
+	people := []Person{{Name: "A", Marks: lektor}, {Name: "U", Marks: lektor}}
+	maps := []RoleMap{{Role: "Lektor 1", SourceColumn: "Lektor", Services: []string{"07"}}}
+	d1 := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC)
+	assign := Assignment{
+		d1: {"07": {"Lektor 1": {"A"}}},
+		d2: {"07": {"Lektor 1": {"A"}}},
+	}
+	backups := Assignment{
+		d1: {"07": {"Lektor 1": {"U"}}},
+		d2: {"07": {"Lektor 1": {"U"}}},
+	}
+
+	swaps := rebalance(assign, []time.Time{d1, d2}, nil, nil, people, maps, 1, 0, 0, false)
+	if len(swaps) != 1 || swaps[0].In != "U" || !swaps[0].Date.Equal(d1) {
+		t.Fatalf("swaps = %+v, ingin U masuk 03-08", swaps)
+	}
+	scheduler.PruneBackups(backups, assign, nil, people, false)
+	if got := backups[d1]["07"]["Lektor 1"]; len(got) != 0 {
+		t.Errorf("cadangan 03-08 = %v, ingin kosong (U sudah bertugas)", got)
+	}
+	if got := backups[d2]["07"]["Lektor 1"]; !reflect.DeepEqual(got, []string{"U"}) {
+		t.Errorf("cadangan 10-08 = %v, ingin [U]", got)
+	}
+}
+
+// JSON "day" selalu nama hari Indonesia, tidak ikut -lang.
+func TestWriteJSONDayIndonesian(t *testing.T) {
+	saved := *langFlag
+	defer func() { *langFlag = saved }()
+	*langFlag = "en"
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "out.json")
+	if err := writeJSON(Assignment{d: {"07": {"Lektor 1": {"A"}}}}, nil, []time.Time{d}, path); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"day": "Minggu"`) {
+		t.Errorf("JSON = %s, ingin \"day\": \"Minggu\"", b)
+	}
+}
+
+// TidakBisa "dd" pada run beberapa bulan hanya berlaku di bulan pertama.
+func TestAnchorDayOnly(t *testing.T) {
+	un, err := parseUnavailable("7, 31, 2025-10-12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	people := []Person{{Name: "B", Unavailable: un}, {Name: "A", Unavailable: map[string]bool{"2025-09-07": true}}}
+	names := anchorDayOnly(people, 2025, time.September, time.UTC)
+	if !reflect.DeepEqual(names, []string{"B"}) {
+		t.Errorf("nama = %v, ingin [B]", names)
+	}
+	// 31 September tidak ada: dibuang, bukan meluber ke Oktober
+	if want := map[string]bool{"2025-09-07": true, "2025-10-12": true}; !reflect.DeepEqual(people[0].Unavailable, want) {
+		t.Errorf("TidakBisa = %v, ingin %v", people[0].Unavailable, want)
+	}
+	sep7 := time.Date(2025, 9, 7, 0, 0, 0, 0, time.UTC)
+	oct7 := time.Date(2025, 10, 7, 0, 0, 0, 0, time.UTC)
+	if !scheduler.IsUnavailable(people[0], sep7) || scheduler.IsUnavailable(people[0], oct7) {
+		t.Errorf("7 Sep harus TidakBisa, 7 Okt tidak")
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// ==================== Sumber Master (.xlsx / .ods / CSV) ====================
+
+// readMasterRows mengembalikan baris sheet Petugas & MappingRole dari -master,
+// dipilih menurut path: folder -> Petugas.csv & MappingRole.csv, .ods ->
+// reader OpenDocument sendiri (excelize tidak mendukung ODS), selain itu
+// excelize. Konvensi kolom sama untuk semua format.
+func readMasterRows(path string) (petRows, relRows [][]string, err error) {
+	if st, err := os.Stat(path); err == nil && st.IsDir() {
+		return readMasterCSV(path)
+	}
+	if strings.EqualFold(filepath.Ext(path), ".ods") {
+		sheets, err := readODS(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		return pickMasterSheets(sheets)
+	}
+
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	petugasSheet := findSheet(f, []string{"Petugas"})
+	if petugasSheet == "" {
+		return nil, nil, errors.New("Sheet Petugas tidak ditemukan")
+	}
+	mappingSheet := findSheet(f, []string{"MappingRole"})
+	if mappingSheet == "" {
+		return nil, nil, errors.New("Sheet MappingRole tidak ditemukan")
+	}
+	petRows, _ = f.GetRows(petugasSheet)
+	relRows, _ = f.GetRows(mappingSheet)
+	return petRows, relRows, nil
+}
+
+// pickMasterSheets: sheet Petugas & MappingRole (nama tanpa beda huruf besar/kecil).
+func pickMasterSheets(sheets map[string][][]string) (petRows, relRows [][]string, err error) {
+	find := func(name string) ([][]string, bool) {
+		for s, rows := range sheets {
+			if strings.EqualFold(s, name) {
+				return rows, true
+			}
+		}
+		return nil, false
+	}
+	petRows, ok := find("Petugas")
+	if !ok {
+		return nil, nil, errors.New("Sheet Petugas tidak ditemukan")
+	}
+	relRows, ok = find("MappingRole")
+	if !ok {
+		return nil, nil, errors.New("Sheet MappingRole tidak ditemukan")
+	}
+	return petRows, relRows, nil
+}
+
+// readMasterCSV: folder berisi Petugas.csv & MappingRole.csv (nama file tanpa
+// beda huruf besar/kecil), satu file per sheet.
+func readMasterCSV(dir string) (petRows, relRows [][]string, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	sheets := map[string][][]string{}
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.EqualFold(filepath.Ext(name), ".csv") {
+			continue
+		}
+		rows, err := readCSVRows(filepath.Join(dir, name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		sheets[strings.TrimSuffix(name, filepath.Ext(name))] = rows
+	}
+	petRows, relRows, err = pickMasterSheets(sheets)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w (folder CSV butuh Petugas.csv & MappingRole.csv)", err)
+	}
+	return petRows, relRows, nil
+}
+
+// readCSVRows membaca satu CSV; pemisah ';' (ekspor Excel berlocale
+// Indonesia) dikenali dari baris header, BOM UTF-8 dibuang.
+func readCSVRows(path string) ([][]string, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	r := csv.NewReader(bytes.NewReader(b))
+	header, _, _ := bytes.Cut(b, []byte("\n"))
+	if bytes.Count(header, []byte(";")) > bytes.Count(header, []byte(",")) {
+		r.Comma = ';'
+	}
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	return r.ReadAll()
+}
+
+// ==================== Reader .ods ====================
+
+const (
+	odsOfficeNS = "urn:oasis:names:tc:opendocument:xmlns:office:1.0"
+	odsTableNS  = "urn:oasis:names:tc:opendocument:xmlns:table:1.0"
+	odsTextNS   = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// readODS membaca semua sheet content.xml sebuah .ods menjadi baris teks
+// (teks sel seperti tampil, paragraf dipisah "\n"), seperti excelize GetRows:
+// sel & baris kosong di ujung dibuang, atribut *-repeated diperluas.
+func readODS(path string) (map[string][][]string, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	var content *zip.File
+	for _, f := range zr.File {
+		if f.Name == "content.xml" {
+			content = f
+		}
+	}
+	if content == nil {
+		return nil, errors.New("bukan file .ods: content.xml tidak ada")
+	}
+	rc, err := content.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	sheets := map[string][][]string{}
+	var (
+		sheet      string
+		rows       [][]string
+		emptyRows  int // baris kosong yang belum ditulis (dibuang bila di ujung)
+		row        []string
+		emptyCells int
+		rowRepeat  int
+		inCell     bool
+		cellRepeat int
+		text       strings.Builder
+		paragraphs int
+	)
+	repeat := func(se xml.StartElement, name string) int {
+		for _, a := range se.Attr {
+			if a.Name.Space == odsTableNS && a.Name.Local == name {
+				if n, err := strconv.Atoi(a.Value); err == nil && n > 0 {
+					return n
+				}
+			}
+		}
+		return 1
+	}
+
+	dec := xml.NewDecoder(rc)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("membaca .ods: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch {
+			case t.Name.Space == odsOfficeNS && t.Name.Local == "annotation":
+				if err := dec.Skip(); err != nil { // komentar sel bukan isi sel
+					return nil, fmt.Errorf("membaca .ods: %w", err)
+				}
+			case t.Name.Space == odsTableNS && t.Name.Local == "table":
+				sheet, rows, emptyRows = "", nil, 0
+				for _, a := range t.Attr {
+					if a.Name.Space == odsTableNS && a.Name.Local == "name" {
+						sheet = a.Value
+					}
+				}
+			case t.Name.Space == odsTableNS && t.Name.Local == "table-row":
+				row, emptyCells, rowRepeat = nil, 0, repeat(t, "number-rows-repeated")
+			case t.Name.Space == odsTableNS && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				inCell, cellRepeat = true, repeat(t, "number-columns-repeated")
+				text.Reset()
+				paragraphs = 0
+			case inCell && t.Name.Space == odsTextNS:
+				switch t.Name.Local {
+				case "p":
+					if paragraphs > 0 {
+						text.WriteByte('\n')
+					}
+					paragraphs++
+				case "s":
+					n := 1
+					for _, a := range t.Attr {
+						if a.Name.Local == "c" {
+							if c, err := strconv.Atoi(a.Value); err == nil && c > 0 {
+								n = c
+							}
+						}
+					}
+					text.WriteString(strings.Repeat(" ", n))
+				case "tab":
+					text.WriteByte('\t')
+				case "line-break":
+					text.WriteByte('\n')
+				}
+			}
+		case xml.CharData:
+			if inCell && paragraphs > 0 {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			switch {
+			case t.Name.Space == odsTableNS && (t.Name.Local == "table-cell" || t.Name.Local == "covered-table-cell"):
+				inCell = false
+				if text.Len() == 0 {
+					emptyCells += cellRepeat
+					continue
+				}
+				for ; emptyCells > 0; emptyCells-- {
+					row = append(row, "")
+				}
+				for i := 0; i < cellRepeat; i++ {
+					row = append(row, text.String())
+				}
+			case t.Name.Space == odsTableNS && t.Name.Local == "table-row":
+				if len(row) == 0 {
+					emptyRows += rowRepeat
+					continue
+				}
+				for ; emptyRows > 0; emptyRows-- {
+					rows = append(rows, nil)
+				}
+				for i := 0; i < rowRepeat; i++ {
+					rows = append(rows, append([]string(nil), row...))
+				}
+			case t.Name.Space == odsTableNS && t.Name.Local == "table":
+				sheets[sheet] = rows
+			}
+		}
+	}
+	if len(sheets) == 0 {
+		return nil, errors.New("tidak ada sheet di file .ods")
+	}
+	return sheets, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// mergeColumns memetakan tiap tanggal ke kolom (1-based) file -merge lewat
+// header tanggal yang sudah terisi, jadi -tgl 17 menulis ke kolom 17-an,
+// bukan kolom tanggal pertama.
+func mergeColumns(f *excelize.File, sheet string, dates []time.Time, loc *time.Location) ([]int, error) {
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	colDate := headerDateColumns(rows, loc)
+	colIdx := make([]int, 0, len(colDate))
+	for c := range colDate {
+		colIdx = append(colIdx, c)
+	}
+	sort.Ints(colIdx)
+
+	cols := make([]int, len(dates))
+	var missing []string
+	for i, d := range dates {
+		for _, c := range colIdx {
+			if scheduler.SameDay(colDate[c], d) {
+				cols[i] = c + 1
+				break
+			}
+		}
+		if cols[i] == 0 {
+			missing = append(missing, d.Format("2006-01-02"))
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("tanggal %s tidak ada di header file -merge", strings.Join(missing, ", "))
+	}
+	return cols, nil
+}
+
+// checkMerge: file -merge bisa dibuka dan memuat semua tanggal run ini
+// (dicek sebelum generate).
+func checkMerge(path, sheetName string, dates []time.Time, loc *time.Location) error {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return fmt.Errorf("membuka file -merge: %w", err)
+	}
+	defer f.Close()
+	sheet, err := requireSheet(f, sheetName)
+	if err != nil {
+		return fmt.Errorf("file -merge %s: %w", path, err)
+	}
+	_, err = mergeColumns(f, sheet, dates, loc)
+	return err
+}
+
+// loadLockedDates membaca isi tanggal -lockDates dari file -merge. Kunci
+// hasilnya tanggal persis dari dates, jadi generate, rebalance, dan writer
+// bisa mengenalinya langsung.
+func loadLockedDates(list, mergePath string, dates []time.Time, maps []RoleMap, loc *time.Location) (Assignment, error) {
+	if mergePath == "" {
+		return nil, errors.New("-lockDates butuh -merge (isi tanggal terkunci dibaca dari file itu)")
+	}
+	want, err := parseDateList(list, loc)
+	if err != nil {
+		return nil, fmt.Errorf("-lockDates: %w", err)
+	}
+	old, err := loadScheduleAssign(mergePath, maps, loc)
+	if err != nil {
+		return nil, fmt.Errorf("membaca -merge untuk -lockDates: %w", err)
+	}
+	res := Assignment{}
+	for _, w := range want {
+		i := -1
+		for j, d := range dates {
+			if scheduler.SameDay(d, w) {
+				i = j
+			}
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("-lockDates: tanggal %s tidak ada di jadwal run ini", w.Format("2006-01-02"))
+		}
+		var day map[string]map[string][]string
+		for od, v := range old {
+			if scheduler.SameDay(od, w) {
+				day = v
+			}
+		}
+		if day == nil {
+			return nil, fmt.Errorf("-lockDates: tanggal %s tidak ada di header file -merge", w.Format("2006-01-02"))
+		}
+		res[dates[i]] = day
+	}
+	if len(res) == len(dates) {
+		return nil, errors.New("-lockDates mengunci semua tanggal; tidak ada yang dijadwalkan ulang")
+	}
+	return res, nil
+}
+
+// filterRoles: salinan a dengan role yang role dasarnya ada di only (keep)
+// atau justru tidak ada di only (!keep); only nil = semua role cocok, jadi
+// a dikembalikan apa adanya untuk keep.
+func filterRoles(a Assignment, only map[string]bool, keep bool) Assignment {
+	if only == nil || a == nil {
+		if keep {
+			return a
+		}
+		return nil
+	}
+	res := Assignment{}
+	for d, day := range a {
+		res[d] = map[string]map[string][]string{}
+		for svc, roles := range day {
+			res[d][svc] = map[string][]string{}
+			for role, names := range roles {
+				if only[scheduler.BaseRole(role)] == keep {
+					res[d][svc][role] = names
+				}
+			}
+		}
+	}
+	return res
+}
+
+// loadKeptRoles membaca isi role di luar -roles dari file -merge: role itu
+// tidak ditulis ulang, tetapi petugasnya tetap dihitung saat role terpilih
+// di-generate. Tanggal yang ada di dates memakai kunci persis dari dates.
+func loadKeptRoles(mergePath string, dates []time.Time, maps []RoleMap, only map[string]bool, loc *time.Location) (Assignment, error) {
+	old, err := loadScheduleAssign(mergePath, maps, loc)
+	if err != nil {
+		return nil, fmt.Errorf("membaca -merge untuk -roles: %w", err)
+	}
+	res := Assignment{}
+	for od, day := range filterRoles(old, only, false) {
+		key := od
+		for _, d := range dates {
+			if scheduler.SameDay(d, od) {
+				key = d
+			}
+		}
+		res[key] = day
+	}
+	return res, nil
+}
+
+// unlockedDates: dates tanpa tanggal -lockDates (kolom yang ditulis ke file -merge).
+func unlockedDates(dates []time.Time, locked Assignment) []time.Time {
+	var res []time.Time
+	for _, d := range dates {
+		if _, ok := locked[d]; !ok {
+			res = append(res, d)
+		}
+	}
+	return res
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// ==================== Normalisasi nama (-normalizeNames) ====================
+
+// nameKey: kunci pembanding nama (scheduler.NormKey: spasi dirapatkan, huruf
+// kecil), dipakai mendeteksi "Budi " / "budi" / "Budi  Santoso" sebagai orang yang sama.
+func nameKey(s string) string {
+	return scheduler.NormKey(s)
+}
+
+// canonName: nama seperti disimpan. Tanpa -normalizeNames hanya di-trim
+// (perilaku lama); dengan -normalizeNames spasi ganda di tengah dirapatkan.
+func canonName(s string, normalize bool) string {
+	if normalize {
+		return strings.Join(strings.Fields(s), " ")
+	}
+	return strings.TrimSpace(s)
+}
+
+// nameGroup: penulisan berbeda dari satu nama di sheet Petugas.
+type nameGroup struct {
+	Key      string
+	Spelling []string // urut kemunculan, termasuk duplikat persis
+	Rows     []int    // nomor baris sheet Petugas, sejajar dengan Spelling
+}
+
+// nearDuplicateNames mengelompokkan baris Petugas yang namanya sama menurut
+// nameKey; hanya kelompok dengan >1 baris yang dikembalikan, urut kemunculan.
+// rows = nomor baris sheet per orang (sejajar dengan people).
+func nearDuplicateNames(people []Person, rows []int) []nameGroup {
+	idx := map[string]int{}
+	var groups []nameGroup
+	for j, p := range people {
+		k := nameKey(p.Name)
+		i, ok := idx[k]
+		if !ok {
+			i = len(groups)
+			idx[k] = i
+			groups = append(groups, nameGroup{Key: k})
+		}
+		groups[i].Spelling = append(groups[i].Spelling, p.Name)
+		groups[i].Rows = append(groups[i].Rows, rows[j])
+	}
+	var res []nameGroup
+	for _, g := range groups {
+		if len(g.Spelling) > 1 {
+			res = append(res, g)
+		}
+	}
+	return res
+}
+
+// mergeDuplicateNames menyatukan baris Petugas dengan nameKey sama menjadi
+// satu orang (penulisan pertama dipakai): tanda/Penatua di-OR, bobot diambil
+// terbesar, TidakBisa & Hindari digabung, Keluarga/Pasangan ambil yang terisi.
+// Referensi Pasangan/Hindari ikut diarahkan ke penulisan kanonik.
+func mergeDuplicateNames(people []Person) []Person {
+	idx := map[string]int{}
+	var res []Person
+	for _, p := range people {
+		k := nameKey(p.Name)
+		i, ok := idx[k]
+		if !ok {
+			idx[k] = len(res)
+			res = append(res, p)
+			continue
+		}
+		q := &res[i]
+		q.IsPenatua = q.IsPenatua || p.IsPenatua
+		for h, v := range p.Marks {
+			q.Marks[h] = q.Marks[h] || v
+		}
+		for h, w := range p.Weights {
+			if w > q.Weights[h] {
+				q.Weights[h] = w
+			}
+		}
+		if len(p.Unavailable) > 0 {
+			if q.Unavailable == nil {
+				q.Unavailable = map[string]bool{}
+			}
+			for d := range p.Unavailable {
+				q.Unavailable[d] = true
+			}
+		}
+		if q.Household == "" {
+			q.Household = p.Household
+		}
+		if q.Partner == "" {
+			q.Partner = p.Partner
+		}
+		q.Avoid = append(q.Avoid, p.Avoid...)
+	}
+
+	canon := func(n string) string {
+		if i, ok := idx[nameKey(n)]; ok {
+			return res[i].Name
+		}
+		return n
+	}
+	for i := range res {
+		if res[i].Partner != "" {
+			res[i].Partner = canon(res[i].Partner)
+		}
+		var avoid []string
+		seen := map[string]bool{}
+		for _, a := range res[i].Avoid {
+			a = canon(a)
+			if !seen[a] {
+				seen[a] = true
+				avoid = append(avoid, a)
+			}
+		}
+		res[i].Avoid = avoid
+	}
+	return res
+}
+
+// onDuplicateMode: nilai -onDuplicate; kosong = merge bila -normalizeNames, selain itu warn.
+func onDuplicateMode(v string, normalize bool) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "":
+		if normalize {
+			return "merge", nil
+		}
+		return "warn", nil
+	case "merge", "gabung":
+		return "merge", nil
+	case "error":
+		return "error", nil
+	case "warn":
+		return "warn", nil
+	}
+	return "", fmt.Errorf("-onDuplicate tidak valid: %s (pilih merge, error, atau warn)", v)
+}
+
+// describeGroup: "\"Budi\" (baris 3), \"budi \" (baris 9)".
+func describeGroup(g nameGroup) string {
+	parts := make([]string, len(g.Spelling))
+	for i, s := range g.Spelling {
+		parts[i] = fmt.Sprintf("%q (baris %d)", s, g.Rows[i])
+	}
+	return strings.Join(parts, ", ")
+}
+
+// handleDuplicateNames menerapkan -onDuplicate pada baris Petugas bernama sama.
+func handleDuplicateNames(people []Person, rows []int, mode string) ([]Person, error) {
+	groups := nearDuplicateNames(people, rows)
+	switch mode {
+	case "error":
+		if len(groups) > 0 {
+			var lines []string
+			for _, g := range groups {
+				lines = append(lines, describeGroup(g))
+			}
+			return nil, fmt.Errorf("nama ganda di sheet Petugas (-onDuplicate error):\n  %s", strings.Join(lines, "\n  "))
+		}
+	case "merge":
+		for _, g := range groups {
+			infof("INFO: nama %s digabung menjadi %q\n", describeGroup(g), g.Spelling[0])
+		}
+		return mergeDuplicateNames(people), nil
+	default:
+		for _, g := range groups {
+			infof("WARN: nama ganda/mirip di sheet Petugas: %s; dianggap orang berbeda (pakai -onDuplicate merge untuk menyatukan)\n", describeGroup(g))
+		}
+	}
+	return people, nil
+}
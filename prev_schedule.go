@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// loadPrevSchedule membaca jadwal hasil generate sebelumnya (.xlsx atau .json)
+// dan mengembalikan nama -> tanggal bertugas, untuk seed cooldown.
+func loadPrevSchedule(path string, maps []RoleMap, loc *time.Location) (map[string][]time.Time, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return loadPrevScheduleJSON(path, loc)
+	case ".xlsx":
+		return loadPrevScheduleXLSX(path, maps, loc)
+	}
+	return nil, errors.New("ekstensi harus .xlsx atau .json")
+}
+
+func loadPrevScheduleJSON(path string, loc *time.Location) (map[string][]time.Time, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var days []jsonDay
+	if err := json.Unmarshal(b, &days); err != nil {
+		return nil, err
+	}
+	res := map[string][]time.Time{}
+	for _, day := range days {
+		d, err := time.ParseInLocation("2006-01-02", day.Date, loc)
+		if err != nil {
+			return nil, err
+		}
+		for _, roles := range day.Services {
+			for _, names := range roles {
+				for _, n := range names {
+					addServed(res, n, d)
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// Header tanggal hasil replacePlaceholders, mis. "Minggu, 03 Agustus 2025".
+var headerDateRe = regexp.MustCompile(`(\d{1,2})\s+([A-Za-z]+)\s+(\d{4})`)
+
+// loadPrevScheduleXLSX membaca layout template: kolom A = label role,
+// baris header berisi tanggal per kolom; role dicocokkan ke MappingRole.
+func loadPrevScheduleXLSX(path string, maps []RoleMap, loc *time.Location) (map[string][]time.Time, error) {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sheet, err := requireSheet(f, *sheetFlag)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+
+	colDate := headerDateColumns(rows, loc)
+	if len(colDate) == 0 {
+		return nil, errors.New("tidak ada header tanggal yang dikenali")
+	}
+
+	res := map[string][]time.Time{}
+	for _, r := range rows {
+		if len(r) == 0 || !isRoleLabel(r[0], maps) {
+			continue
+		}
+		for c, d := range colDate {
+			if c >= len(r) {
+				continue
+			}
+			for _, n := range strings.Split(r[c], "\n") {
+				if n = strings.TrimSpace(n); n != "" && !isBackupLine(n) {
+					addServed(res, n, d)
+				}
+			}
+		}
+	}
+	return res, nil
+}
+
+// headerDateColumns: indeks kolom (0-based) -> tanggal, dari sel header
+// yang placeholder-nya sudah terisi. Tanggal pertama per kolom yang dipakai.
+func headerDateColumns(rows [][]string, loc *time.Location) map[int]time.Time {
+	colDate := map[int]time.Time{}
+	for _, r := range rows {
+		for c := 1; c < len(r); c++ {
+			if _, ok := colDate[c]; ok {
+				continue
+			}
+			m := headerDateRe.FindStringSubmatch(r[c])
+			if m == nil {
+				continue
+			}
+			month, err := parseMonth(m[2])
+			if err != nil {
+				continue
+			}
+			day, _ := strconv.Atoi(m[1])
+			year, _ := strconv.Atoi(m[3])
+			if d, err := safeDate(year, month, day, loc); err == nil {
+				colDate[c] = d
+			}
+		}
+	}
+	return colDate
+}
+
+// isRoleLabel: label kolom A cocok dengan salah satu role MappingRole
+// (case-insensitive, sufiks ibadah diabaikan; Majelis Pendamping fuzzy seperti roleRowIndex).
+func isRoleLabel(label string, maps []RoleMap) bool {
+	lab, _ := splitServiceLabel(label)
+	if lab == "" {
+		return false
+	}
+	for _, m := range maps {
+		if strings.EqualFold(strings.TrimSpace(m.Role), lab) {
+			return true
+		}
+		if scheduler.IsMajelisPendamping(m.Role) && scheduler.IsMajelisPendamping(lab) {
+			return true
+		}
+	}
+	return false
+}
+
+func addServed(res map[string][]time.Time, name string, d time.Time) {
+	for _, t := range res[name] {
+		if scheduler.SameDay(t, d) {
+			return
+		}
+	}
+	res[name] = append(res[name], d)
+}
+
+// servedFromAssign: nama -> tanggal bertugas dari hasil generate (untuk
+// melanjutkan cooldown ke bulan berikutnya pada -months).
+func servedFromAssign(assign Assignment, dates []time.Time) map[string][]time.Time {
+	res := map[string][]time.Time{}
+	for _, d := range dates {
+		for _, roles := range assign[d] {
+			for _, names := range roles {
+				for _, n := range names {
+					addServed(res, n, d)
+				}
+			}
+		}
+	}
+	return res
+}
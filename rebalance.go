@@ -0,0 +1,190 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// rebalanceSwap mencatat satu penggantian hasil rebalance.
+type rebalanceSwap struct {
+	Date     time.Time
+	Service  string
+	Role     string
+	Out, In  string
+	OutCount int // jumlah tugas Out sebelum diganti
+}
+
+// rebalance menukar petugas yang tugasnya > minPer dengan orang eligible
+// yang tugasnya < minPer, selama batasan tetap terpenuhi: eligibility
+// (Penatua untuk MP), tipe komposisi P/J sama, TidakBisa, tidak bertugas
+// di tanggal yang sama, cooldown (per role bila diisi di MappingRole),
+// -maxPerPerson, Hindari, dan keluarga (noSameHousehold); orang -pin dan tanggal
+// -lockDates tidak diganti. kept (boleh nil) = role lain yang tidak
+// di-generate (-roles dengan -merge): petugasnya ikut dicek untuk tanggal
+// yang sama, cooldown, Hindari, dan keluarga, tetapi tidak pernah diganti.
+// Setiap swap tidak membuat orang lain turun di bawah minPer, jadi proses
+// berhenti dan menjalankannya ulang tidak mengubah apa pun.
+func rebalance(assign Assignment, dates []time.Time, locked, kept Assignment, people []Person, maps []RoleMap,
+	minPer, cooldown, maxPer int, noSameHousehold bool) []rebalanceSwap {
+
+	roleIdx := map[string]RoleMap{}
+	for _, m := range maps {
+		if _, ok := roleIdx[m.Role]; !ok {
+			roleIdx[m.Role] = m
+		}
+	}
+	personIdx := map[string]Person{}
+	for _, p := range people {
+		personIdx[p.Name] = p
+	}
+
+	counts := map[string]int{}
+	servedOn := map[string]map[int]bool{} // nama -> indeks tanggal
+	for di, d := range dates {
+		for _, roles := range assign[d] {
+			for _, names := range roles {
+				for _, n := range names {
+					counts[n]++
+					if servedOn[n] == nil {
+						servedOn[n] = map[int]bool{}
+					}
+					servedOn[n][di] = true
+				}
+			}
+		}
+		for _, roles := range kept[d] {
+			for _, names := range roles {
+				for _, n := range names {
+					if servedOn[n] == nil {
+						servedOn[n] = map[int]bool{}
+					}
+					servedOn[n][di] = true
+				}
+			}
+		}
+	}
+
+	// servesOn: name masih bertugas di tanggal d (hasil generate atau kept)
+	servesOn := func(name string, d time.Time) bool {
+		for _, day := range []map[string]map[string][]string{assign[d], kept[d]} {
+			for _, roles := range day {
+				for _, names := range roles {
+					for _, n := range names {
+						if n == name {
+							return true
+						}
+					}
+				}
+			}
+		}
+		return false
+	}
+
+	eligible := func(p Person, m RoleMap) bool {
+		if scheduler.IsMajelisPendamping(m.Role) && !p.IsPenatua {
+			return false
+		}
+		return p.Marks[scheduler.NormKey(m.SourceColumn)]
+	}
+	avoidIdx := scheduler.BuildAvoidIndex(people)
+	// u boleh masuk ibadah ini menggantikan out: tidak satu keluarga / Hindari
+	// dengan petugas lain di ibadah yang sama
+	fitsService := func(u Person, d time.Time, svc, out string) bool {
+		for _, roles := range []map[string][]string{assign[d][svc], kept[d][svc]} {
+			for _, names := range roles {
+				for _, n := range names {
+					if n == out {
+						continue
+					}
+					if avoidIdx[u.Name][n] {
+						return false
+					}
+					if noSameHousehold && u.Household != "" && personIdx[n].Household == u.Household {
+						return false
+					}
+				}
+			}
+		}
+		return true
+	}
+	// tanggal di sekitar di (dalam jendela cooldown role) tidak boleh sudah terisi
+	restOK := func(name string, di, cooldown int) bool {
+		for k := range servedOn[name] {
+			if k == di || (cooldown > 0 && k > di-1-cooldown && k < di+1+cooldown) {
+				return false
+			}
+		}
+		return true
+	}
+
+	var under []Person
+	for _, p := range people {
+		if counts[p.Name] < minPer {
+			under = append(under, p)
+		}
+	}
+	sort.Slice(under, func(i, j int) bool { return under[i].Name < under[j].Name })
+
+	var swaps []rebalanceSwap
+	for _, u := range under {
+		for counts[u.Name] < minPer && (maxPer <= 0 || counts[u.Name] < maxPer) {
+			best := rebalanceSwap{}
+			bestIdx, bestDi := -1, -1
+			for di, d := range dates {
+				if _, ok := locked[d]; ok || scheduler.IsUnavailable(u, d) || !restOK(u.Name, di, 0) {
+					continue
+				}
+				for _, svc := range sortedServices(assign[d]) {
+					roles := assign[d][svc]
+					for _, role := range sortedRoles(roles) {
+						m, ok := roleIdx[role]
+						if !ok || m.SamePerson || !eligible(u, m) || !restOK(u.Name, di, scheduler.RoleCooldown([]RoleMap{m}, cooldown)) {
+							continue
+						}
+						comp := scheduler.BaseRole(role) == "kolektan" || scheduler.BaseRole(role) == "pjemaat"
+						for i, h := range roles[role] {
+							if counts[h] <= minPer || counts[h] <= best.OutCount || pinFlag.pinnedAt(d, svc, h) {
+								continue
+							}
+							if comp && personIdx[h].IsPenatua != u.IsPenatua {
+								continue
+							}
+							if !fitsService(u, d, svc, h) {
+								continue
+							}
+							best = rebalanceSwap{Date: d, Service: svc, Role: role, Out: h, In: u.Name, OutCount: counts[h]}
+							bestIdx, bestDi = i, di
+						}
+					}
+				}
+			}
+			if bestIdx < 0 {
+				break
+			}
+			assign[best.Date][best.Service][best.Role][bestIdx] = u.Name
+			counts[best.Out]--
+			counts[u.Name]++
+			// Out bisa masih bertugas di slot/ibadah lain (atau role kept) hari itu
+			if !servesOn(best.Out, best.Date) {
+				delete(servedOn[best.Out], bestDi)
+			}
+			if servedOn[u.Name] == nil {
+				servedOn[u.Name] = map[int]bool{}
+			}
+			servedOn[u.Name][bestDi] = true
+			swaps = append(swaps, best)
+		}
+	}
+	return swaps
+}
+
+// printSwaps mencetak hasil rebalance (hanya dengan -v).
+func printSwaps(swaps []rebalanceSwap) {
+	verbosef("Rebalance: %d penggantian\n", len(swaps))
+	for _, s := range swaps {
+		verbosef("  %s %s (%s.00): %s (%dx) -> %s\n",
+			s.Date.Format("02-01-2006"), s.Role, s.Service, s.Out, s.OutCount, s.In)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// personTally adalah rekap penugasan satu orang selama periode.
+type personTally struct {
+	Name  string
+	BySvc map[string]int // service -> jumlah
+	Total int
+	Roles map[string]int // label role (tanpa nomor urut) -> jumlah
+}
+
+// tallyAssignments menghitung penugasan per orang dengan menelusuri
+// Assignment final (bukan dari picker), diurutkan Total desc lalu nama.
+func tallyAssignments(assign Assignment, dates []time.Time) []personTally {
+	idx := map[string]*personTally{}
+	for _, d := range dates {
+		for svc, roles := range assign[d] {
+			for role, names := range roles {
+				for _, n := range names {
+					t := idx[n]
+					if t == nil {
+						t = &personTally{Name: n, BySvc: map[string]int{}, Roles: map[string]int{}}
+						idx[n] = t
+					}
+					t.BySvc[svc]++
+					t.Total++
+					t.Roles[roleLabel(role)]++
+				}
+			}
+		}
+	}
+	res := make([]personTally, 0, len(idx))
+	for _, t := range idx {
+		res = append(res, *t)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Total != res[j].Total {
+			return res[i].Total > res[j].Total
+		}
+		return res[i].Name < res[j].Name
+	})
+	return res
+}
+
+// fairnessStats: sebaran jumlah tugas atas semua petugas eligible, termasuk
+// yang tidak kebagian tugas (Idle). StdDev tinggi = beban timpang.
+type fairnessStats struct {
+	Mean, StdDev   float64
+	Eligible, Idle int
+}
+
+// eligibleNames: petugas yang eligible untuk minimal satu kolom sumber
+// MappingRole (ketersediaan per tanggal diabaikan).
+func eligibleNames(people []Person, maps []RoleMap) []string {
+	set := map[string]bool{}
+	for _, m := range maps {
+		pen, jem := scheduler.EligibleFor(people, scheduler.NormKey(m.SourceColumn), time.Time{})
+		for _, c := range append(pen, jem...) {
+			set[c.Name] = true
+		}
+	}
+	names := make([]string, 0, len(set))
+	for n := range set {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fairness menghitung rata-rata & simpangan baku (populasi) jumlah tugas.
+// Orang yang bertugas tapi tidak ada di eligible tetap dihitung.
+func fairness(tallies []personTally, eligible []string) fairnessStats {
+	counts := map[string]int{}
+	for _, n := range eligible {
+		counts[n] = 0
+	}
+	for _, t := range tallies {
+		counts[t.Name] = t.Total
+	}
+	st := fairnessStats{Eligible: len(counts)}
+	if len(counts) == 0 {
+		return st
+	}
+	sum := 0
+	for _, c := range counts {
+		sum += c
+		if c == 0 {
+			st.Idle++
+		}
+	}
+	st.Mean = float64(sum) / float64(len(counts))
+	var sq float64
+	for _, c := range counts {
+		sq += (float64(c) - st.Mean) * (float64(c) - st.Mean)
+	}
+	st.StdDev = math.Sqrt(sq / float64(len(counts)))
+	return st
+}
+
+// printReport mencetak tabel rekap per orang plus metrik keadilan
+// (selisih tiap orang terhadap rata-rata, simpangan baku). maxWarn > 0
+// menandai orang yang bertugas lebih dari maxWarn kali.
+func printReport(assign Assignment, dates []time.Time, people []Person, maps []RoleMap, maxWarn int) {
+	tallies := tallyAssignments(assign, dates)
+	fair := fairness(tallies, eligibleNames(people, maps))
+	svcSet := map[string]map[string][]string{}
+	for _, d := range dates {
+		for svc := range assign[d] {
+			svcSet[svc] = nil
+		}
+	}
+	services := sortedServices(svcSet)
+	nameW := len(tr("Nama"))
+	for _, t := range tallies {
+		if len(t.Name) > nameW {
+			nameW = len(t.Name)
+		}
+	}
+
+	infoln()
+	infoln(tr("Rekap penugasan per petugas:"))
+	infof("  %-*s", nameW, tr("Nama"))
+	for _, svc := range services {
+		infof("  %3s", svc)
+	}
+	infof("  %5s  %7s  %s\n", "Total", tr("Selisih"), "Role")
+	var over []personTally
+	for _, t := range tallies {
+		mark := ""
+		if maxWarn > 0 && t.Total > maxWarn {
+			mark = paint(ansiYellow, " (!)")
+			over = append(over, t)
+		}
+		infof("  %-*s", nameW, t.Name)
+		for _, svc := range services {
+			infof("  %3d", t.BySvc[svc])
+		}
+		infof("  %5d  %+7.1f  %s%s\n", t.Total, float64(t.Total)-fair.Mean, formatRoleCounts(t.Roles), mark)
+	}
+	for _, t := range over {
+		infof(paint(ansiYellow, tr("WARN: %s bertugas %d kali (batas %d)\n")), t.Name, t.Total, maxWarn)
+	}
+	infof(tr("Keadilan: rata-rata %.2f tugas/orang, simpangan baku %.2f (%d eligible, %d tanpa tugas)\n"),
+		fair.Mean, fair.StdDev, fair.Eligible, fair.Idle)
+}
+
+func formatRoleCounts(m map[string]int) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%s x%d", k, m[k]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// roleLabel membuang nomor urut di akhir label role ("Lektor 2" -> "Lektor").
+func roleLabel(role string) string {
+	r := strings.TrimSpace(role)
+	t := strings.TrimRight(r, "0123456789")
+	if t == r {
+		return r
+	}
+	if t = strings.TrimSpace(t); t == "" {
+		return r
+	}
+	return t
+}
@@ -0,0 +1,78 @@
+package scheduler
+
+// backupRules: syarat cadangan terhadap petugas satu tanggal, sama untuk
+// saat dipilih (GenerateSchedule) maupun dicek ulang (PruneBackups): tidak
+// bertugas di tanggal itu, tidak Hindari dan (dengan NoSameHousehold) tidak
+// satu keluarga dengan petugas ibadahnya.
+type backupRules struct {
+	avoid     map[string]map[string]bool
+	household map[string]string // nil = tanpa cek keluarga
+}
+
+func newBackupRules(people []Person, noSameHousehold bool) backupRules {
+	r := backupRules{avoid: BuildAvoidIndex(people)}
+	if noSameHousehold {
+		r.household = map[string]string{}
+		for _, p := range people {
+			r.household[p.Name] = p.Household
+		}
+	}
+	return r
+}
+
+// servingOn: nama yang bertugas di tanggal itu, semua ibadah dan per ibadah.
+// days = isi tanggal itu dari beberapa sumber (hasil generate, Kept).
+func servingOn(days ...map[string]map[string][]string) (today map[string]bool, bySvc map[string]map[string]bool) {
+	today, bySvc = map[string]bool{}, map[string]map[string]bool{}
+	for _, day := range days {
+		for svc, roles := range day {
+			if bySvc[svc] == nil {
+				bySvc[svc] = map[string]bool{}
+			}
+			for _, names := range roles {
+				for _, n := range names {
+					today[n] = true
+					bySvc[svc][n] = true
+				}
+			}
+		}
+	}
+	return today, bySvc
+}
+
+// ok: name boleh jadi cadangan di ibadah dengan petugas svc.
+func (r backupRules) ok(name string, today, svc map[string]bool) bool {
+	if today[name] || avoidConflict(r.avoid, name, svc) {
+		return false
+	}
+	if h := r.household[name]; h != "" {
+		for other := range svc {
+			if r.household[other] == h {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// PruneBackups membuang cadangan yang tidak lagi memenuhi syarat setelah
+// assign berubah (mis. -rebalance menukar cadangan ke slot utama). kept =
+// Config.Kept (boleh nil); backups nil = tanpa cadangan.
+func PruneBackups(backups, assign, kept Assignment, people []Person, noSameHousehold bool) {
+	rules := newBackupRules(people, noSameHousehold)
+	keptDay := lockedByDate(kept)
+	for d, day := range backups {
+		today, bySvc := servingOn(assign[d], keptDay[d.Format("2006-01-02")])
+		for svc, roles := range day {
+			for role, names := range roles {
+				valid := names[:0]
+				for _, n := range names {
+					if rules.ok(n, today, bySvc[svc]) {
+						valid = append(valid, n)
+					}
+				}
+				roles[role] = valid
+			}
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// benchMaster: master sintetis 200 orang x 30 role (dua ibadah). Tiap orang
+// bertanda di sekitar sepertiga kolom; setiap orang keempat Penatua, dua
+// orang berurutan satu keluarga.
+func benchMaster() ([]Person, []RoleMap) {
+	var maps []RoleMap
+	group := func(base string, n int) {
+		for i := 1; i <= n; i++ {
+			maps = append(maps, RoleMap{Role: fmt.Sprintf("%s %d", base, i), SourceColumn: base})
+		}
+	}
+	group("Lektor", 4)
+	group("Prokantor", 3)
+	group("Pemusik", 3)
+	group("Kolektan", 4)
+	group("P. Jemaat", 4)
+	maps = append(maps, RoleMap{Role: "Majelis Pendamping", SourceColumn: "MP"})
+	for _, r := range []string{"DP/PA", "W/PB", "Persembahan", "Multimedia", "Sound", "PF",
+		"Liturgos", "Dekorasi", "Penerima Tamu", "Operator LCD", "Kantor"} {
+		maps = append(maps, RoleMap{Role: r, SourceColumn: r})
+	}
+
+	var cols []string
+	seen := map[string]bool{}
+	for _, m := range maps {
+		if !seen[m.SourceColumn] {
+			seen[m.SourceColumn] = true
+			cols = append(cols, m.SourceColumn)
+		}
+	}
+	people := make([]Person, 200)
+	for i := range people {
+		p := Person{
+			Name:      fmt.Sprintf("Orang %03d", i),
+			IsPenatua: i%4 == 0,
+			Marks:     map[string]bool{},
+			Household: fmt.Sprintf("K%d", i/2),
+		}
+		for c, col := range cols {
+			if (i+c)%3 == 0 || (col == "MP" && p.IsPenatua) {
+				p.Marks[NormKey(col)] = true
+			}
+		}
+		people[i] = p
+	}
+	return people, maps
+}
+
+// Jalankan: go test ./scheduler -run XXX -bench Generate -benchmem
+func BenchmarkGenerateSchedule(b *testing.B) {
+	people, maps := benchMaster()
+	var dates []time.Time
+	for _, day := range []int{3, 10, 17, 24, 31} {
+		dates = append(dates, time.Date(2025, 8, day, 0, 0, 0, 0, time.UTC))
+	}
+
+	cases := []struct {
+		name string
+		set  func(*Config)
+	}{
+		{"default", func(*Config) {}},
+		{"fair", func(c *Config) { c.Fair = true }},
+		{"stableOrder", func(c *Config) { c.StableOrder = true }},
+		{"strict", func(c *Config) {
+			c.StrictComposition = true
+			c.Kolektan = Quota{Penatua: 2, Jemaat: 2}
+		}},
+		{"optimal", func(c *Config) {
+			c.StrictComposition = true
+			c.Optimal = true
+			c.NoSameHousehold = true
+			c.Kolektan = Quota{Penatua: 2, Jemaat: 2}
+		}},
+	}
+	for _, tc := range cases {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				cfg := NewConfig(dates)
+				cfg.Seed = int64(i)
+				cfg.MaxLektor = 4
+				cfg.MaxProkantor = 3
+				cfg.MaxPemusik = 3
+				tc.set(&cfg)
+				if _, err := GenerateSchedule(cfg, people, maps); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
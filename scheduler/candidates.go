@@ -0,0 +1,159 @@
+package scheduler
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// dateRand: RNG sendiri per tanggal dari (seed, tanggal), jadi pengacakan satu
+// tanggal tidak bergantung pada berapa banyak angka acak dipakai tanggal lain.
+// Membuat ulang satu tanggal (mis. -merge/-lockDates) memberi urutan yang sama
+// seperti di run penuh.
+func dateRand(seed int64, d time.Time) *rand.Rand {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d|%s", seed, d.Format("2006-01-02"))
+	return rand.New(rand.NewSource(int64(h.Sum64())))
+}
+
+// shuffleNames mengacak urutan kandidat dengan rng tanggal itu. Dengan
+// -stableOrder, urutan ditentukan hash (seed, tanggal, key, nama) sehingga
+// hasil identik antar mesin/run untuk input & seed yang sama, terlepas dari urutan map.
+func shuffleNames(cfg *Config, rng *rand.Rand, names []string, d time.Time, key string) {
+	if !cfg.StableOrder {
+		rng.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
+		return
+	}
+	sort.SliceStable(names, func(i, j int) bool {
+		return stableLess(cfg.Seed, names[i], names[j], d, key)
+	})
+}
+
+func shufflePeople(cfg *Config, rng *rand.Rand, ps []Person, d time.Time, key string) {
+	if !cfg.StableOrder {
+		rng.Shuffle(len(ps), func(i, j int) { ps[i], ps[j] = ps[j], ps[i] })
+		return
+	}
+	sort.SliceStable(ps, func(i, j int) bool {
+		return stableLess(cfg.Seed, ps[i].Name, ps[j].Name, d, key)
+	})
+}
+
+// biasScores: skor urutan -bias per nama dari urutan acak saat ini,
+// bias*(tugas/tugas terbanyak di daftar) + (1-bias)*(posisi/(n-1)).
+// Skor kecil didahulukan; bias 1 = sama dengan -fair, mendekati 0 = acak.
+func biasScores(names []string, bias float64, count map[string]int) map[string]float64 {
+	top := 0
+	for _, n := range names {
+		if count[n] > top {
+			top = count[n]
+		}
+	}
+	score := make(map[string]float64, len(names))
+	for i, n := range names {
+		use, pos := 0.0, 0.0
+		if top > 0 {
+			use = float64(count[n]) / float64(top)
+		}
+		if len(names) > 1 {
+			pos = float64(i) / float64(len(names)-1)
+		}
+		if _, dup := score[n]; !dup {
+			score[n] = bias*use + (1-bias)*pos
+		}
+	}
+	return score
+}
+
+func stableLess(seed int64, a, b string, d time.Time, key string) bool {
+	ha, hb := stableHash(seed, a, d, key), stableHash(seed, b, d, key)
+	if ha != hb {
+		return ha < hb
+	}
+	return a < b
+}
+
+func stableHash(seed int64, name string, d time.Time, key string) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d|%s|%s|%s", seed, d.Format("2006-01-02"), key, name)
+	return h.Sum64()
+}
+
+// FilterCandidates: d nol berarti tanpa filter tanggal TidakBisa.
+func FilterCandidates(people []Person, src string, mustPenatua bool, d time.Time) []string {
+	key := NormKey(src)
+	m := map[string]struct{}{}
+	for _, p := range people {
+		if mustPenatua && !p.IsPenatua {
+			continue
+		}
+		if IsUnavailable(p, d) {
+			continue
+		}
+		if mark, ok := p.Marks[key]; ok && mark {
+			m[p.Name] = struct{}{}
+		}
+	}
+	var res []string
+	for n := range m {
+		res = append(res, n)
+	}
+	sort.Strings(res)
+	return res
+}
+
+func filterCandidatesSplit(people []Person, src string, d time.Time) (penatua []string, jemaat []string) {
+	pen, jem := EligibleFor(people, NormKey(src), d)
+	return candidateNames(pen), candidateNames(jem)
+}
+
+// Candidate: orang yang eligible untuk satu kolom Petugas beserta bobotnya.
+type Candidate struct {
+	Name   string
+	Weight int // 1 = biasa, 2+ = diutamakan
+}
+
+// EligibleFor mengembalikan Penatua & Jemaat yang eligible untuk kolom key
+// (sudah dinormalisasi, lihat NormKey), terurut nama. d nol berarti tanpa
+// filter tanggal TidakBisa. Kolom yang tidak dikenal menghasilkan daftar kosong.
+func EligibleFor(people []Person, key string, d time.Time) (penatua, jemaat []Candidate) {
+	for _, p := range people {
+		if !p.Marks[key] || IsUnavailable(p, d) {
+			continue
+		}
+		c := Candidate{Name: p.Name, Weight: p.Weights[key]}
+		if c.Weight < 1 {
+			c.Weight = 1 // Marks tanpa Weights (mis. diisi manual oleh pemanggil library)
+		}
+		if p.IsPenatua {
+			penatua = append(penatua, c)
+		} else {
+			jemaat = append(jemaat, c)
+		}
+	}
+	byName := func(cs []Candidate) {
+		sort.SliceStable(cs, func(i, j int) bool { return cs[i].Name < cs[j].Name })
+	}
+	byName(penatua)
+	byName(jemaat)
+	return penatua, jemaat
+}
+
+func candidateNames(cs []Candidate) []string {
+	var res []string
+	for _, c := range cs {
+		res = append(res, c.Name)
+	}
+	return res
+}
+
+// IsUnavailable: true bila tanggal d tercantum di kolom TidakBisa orang tsb.
+func IsUnavailable(p Person, d time.Time) bool {
+	if len(p.Unavailable) == 0 || d.IsZero() {
+		return false
+	}
+	return p.Unavailable[d.Format("2006-01-02")] || p.Unavailable[strconv.Itoa(d.Day())]
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEligibleFor(t *testing.T) {
+	d := time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC)
+	people := []Person{
+		{Name: "Budi", IsPenatua: true, Marks: map[string]bool{"lektor": true}, Weights: map[string]int{"lektor": 2}},
+		{Name: "Ani", IsPenatua: true, Marks: map[string]bool{"lektor": true}}, // tanpa Weights
+		{Name: "Citra", Marks: map[string]bool{"lektor": true, "pemusik": true}, Weights: map[string]int{"lektor": 1, "pemusik": 1}},
+		{Name: "Dedi", Marks: map[string]bool{"lektor": false}, Weights: map[string]int{"lektor": 0}},
+		{Name: "Eka", Marks: map[string]bool{"lektor": true}, Weights: map[string]int{"lektor": 1}, Unavailable: map[string]bool{"10": true}},
+	}
+
+	cases := []struct {
+		name     string
+		key      string
+		d        time.Time
+		pen, jem []Candidate
+	}{
+		{"lektor", "lektor", d,
+			[]Candidate{{"Ani", 1}, {"Budi", 2}}, []Candidate{{"Citra", 1}}},
+		{"tanpa filter tanggal", "lektor", time.Time{},
+			[]Candidate{{"Ani", 1}, {"Budi", 2}}, []Candidate{{"Citra", 1}, {"Eka", 1}}},
+		{"pemusik", "pemusik", d, nil, []Candidate{{"Citra", 1}}},
+		{"kolom tidak dikenal", "multimedia", d, nil, nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			pen, jem := EligibleFor(people, tc.key, tc.d)
+			if !reflect.DeepEqual(pen, tc.pen) || !reflect.DeepEqual(jem, tc.jem) {
+				t.Fatalf("dapat P:%v J:%v, ingin P:%v J:%v", pen, jem, tc.pen, tc.jem)
+			}
+		})
+	}
+
+	// FilterCandidates: gabungan terurut, mustPenatua hanya Penatua
+	if got, want := FilterCandidates(people, " Lektor ", false, d), []string{"Ani", "Budi", "Citra"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterCandidates = %v, ingin %v", got, want)
+	}
+	if got, want := FilterCandidates(people, "Lektor", true, d), []string{"Ani", "Budi"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterCandidates(mustPenatua) = %v, ingin %v", got, want)
+	}
+}
+
+func TestNormKeyOddSpaces(t *testing.T) {
+	cases := map[string]string{
+		"\ufeffNama":            "nama",
+		"P.\u00a0Jemaat":        "p. jemaat",
+		"P.  Jemaat ":           "p. jemaat",
+		"Lek\u200btor":          "lektor",
+		"\u2060Prokantor\u200d": "prokantor",
+		"PF\u202fRemaja":        "pf remaja",
+	}
+	for in, want := range cases {
+		if got := NormKey(in); got != want {
+			t.Errorf("NormKey(%q) = %q, ingin %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package scheduler
+
+import "time"
+
+// lockedDay: isi satu tanggal terkunci, service -> role -> nama.
+type lockedDay = map[string]map[string][]string
+
+// lockedByDate: cfg.Locked per "yyyy-mm-dd", jadi kunci waktu dari pemanggil
+// tidak harus persis sama (zona/jam) dengan cfg.Dates.
+func lockedByDate(locked Assignment) map[string]lockedDay {
+	res := map[string]lockedDay{}
+	for d, day := range locked {
+		res[d.Format("2006-01-02")] = day
+	}
+	return res
+}
+
+// lockedServed: nama -> tanggal terkunci (urut dates) tempat orang itu bertugas.
+func lockedServed(dates []time.Time, locked map[string]lockedDay) map[string][]time.Time {
+	res := map[string][]time.Time{}
+	for _, d := range dates {
+		seen := map[string]bool{}
+		for _, roles := range locked[d.Format("2006-01-02")] {
+			for _, names := range roles {
+				for _, n := range names {
+					if !seen[n] {
+						seen[n] = true
+						res[n] = append(res[n], d)
+					}
+				}
+			}
+		}
+	}
+	return res
+}
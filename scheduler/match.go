@@ -0,0 +1,120 @@
+package scheduler
+
+import "time"
+
+// matchComposition: pengisian komposisi optimal (-optimal) sebagai bipartite
+// matching keluarga -> slot. Slot bertipe pool (P/J) dicocokkan lebih dulu;
+// tanpa strict, sisa slot menerima pool mana pun (seperti relax-any). Satu
+// keluarga (household(name) sama, "" = sendiri) paling banyak satu slot.
+//
+// Hasil: nama per slot terisi, urut pool lalu slot bebas, tanpa efek samping.
+// Kandidat lolos prefer didahulukan; larangan Hindari antar-pick baru tidak
+// dimodelkan, pemanggil memeriksanya lewat blocked saat menerapkan.
+func matchComposition(
+	pools []compPool,
+	d time.Time,
+	prefer func(string) bool,
+	already map[string]bool,
+	assignedAnyToday map[string]bool,
+	blocked func(string) bool,
+	household func(string) string,
+	strict bool,
+	noRelaxB2B bool,
+) []string {
+	// grup = satu keluarga; best[pool] = calon terbaik keluarga itu di pool tsb
+	type group struct{ best []string }
+	var groups []group
+	idx := map[string]int{}
+	for pass := 0; pass < 2; pass++ { // 0: lolos prefer, 1: sisanya (relax)
+		for pi, pl := range pools {
+			for _, p := range pl.Cands {
+				ok := prefer(p.Name)
+				if (pass == 0) != ok || (pass == 1 && noRelaxB2B) {
+					continue
+				}
+				if already[p.Name] || assignedAnyToday[p.Name] || IsUnavailable(p, d) || blocked(p.Name) {
+					continue
+				}
+				key := household(p.Name)
+				if key == "" {
+					key = "\x00" + p.Name
+				}
+				gi, seen := idx[key]
+				if !seen {
+					gi = len(groups)
+					idx[key] = gi
+					groups = append(groups, group{best: make([]string, len(pools))})
+				}
+				if groups[gi].best[pi] == "" {
+					groups[gi].best[pi] = p.Name
+				}
+			}
+		}
+	}
+
+	// slotPool[s]: indeks pool slot s, -1 = pool mana pun
+	var slotPool []int
+	for pi, pl := range pools {
+		for i := 0; i < pl.Need; i++ {
+			slotPool = append(slotPool, pi)
+		}
+	}
+	nameFor := func(g, s int) string {
+		if slotPool[s] >= 0 {
+			return groups[g].best[slotPool[s]]
+		}
+		for _, n := range groups[g].best {
+			if n != "" {
+				return n
+			}
+		}
+		return ""
+	}
+
+	// Kuhn: grup yang sudah cocok tetap cocok, jadi urutan grup = prioritas
+	match := make([]int, len(slotPool)) // slot -> grup, -1 = kosong
+	for s := range match {
+		match[s] = -1
+	}
+	var try func(g int, seen []bool) bool
+	try = func(g int, seen []bool) bool {
+		for s := range slotPool {
+			if seen[s] || nameFor(g, s) == "" {
+				continue
+			}
+			seen[s] = true
+			if match[s] < 0 || try(match[s], seen) {
+				match[s] = g
+				return true
+			}
+		}
+		return false
+	}
+	matched := make([]bool, len(groups))
+	filled := 0
+	for g := range groups {
+		if try(g, make([]bool, len(slotPool))) {
+			matched[g] = true
+			filled++
+		}
+	}
+	// slot bebas (tanpa strict) menerima grup mana pun: cukup sisa grup
+	// berurutan, tanpa menggeser grup yang sudah cocok di slot bertipe
+	if !strict {
+		for g, total := 0, len(slotPool); g < len(groups) && filled < total; g++ {
+			if !matched[g] {
+				slotPool = append(slotPool, -1)
+				match = append(match, g)
+				filled++
+			}
+		}
+	}
+
+	picked := []string{}
+	for s, g := range match {
+		if g >= 0 {
+			picked = append(picked, nameFor(g, s))
+		}
+	}
+	return picked
+}
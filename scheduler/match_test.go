@@ -0,0 +1,100 @@
+package scheduler
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Kasus greedy gagal: P1 (urutan pertama) serumah dengan satu-satunya Jemaat.
+// Greedy mengambil P1 lalu J1 terblokir; matching memilih P2 + J1.
+func TestMatchCompositionGreedyFails(t *testing.T) {
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	pools := []compPool{
+		{Name: "P", Cands: []Person{{Name: "P1", IsPenatua: true}, {Name: "P2", IsPenatua: true}}, Need: 1},
+		{Name: "J", Cands: []Person{{Name: "J1"}}, Need: 1},
+	}
+	house := map[string]string{"P1": "A", "J1": "A"}
+	prefer := func(string) bool { return true }
+
+	// greedy dengan blokir keluarga seperti -noSameHousehold
+	used := map[string]bool{}
+	blocked := func(name string) bool { return house[name] != "" && used[house[name]] }
+	greedy := pickWithComposition(pools, d, nil, func(*rand.Rand, []Person) {}, prefer, map[string]bool{}, map[string]bool{}, blocked,
+		func(name, _ string, _ int, _ *skipped) { used[house[name]] = true }, true, false, nil)
+	if len(greedy) != 1 {
+		t.Fatalf("greedy: dapat %v, kasus uji mengharapkan greedy gagal", greedy)
+	}
+
+	none := func(string) bool { return false }
+	got := matchComposition(pools, d, prefer, map[string]bool{}, map[string]bool{}, none,
+		func(name string) string { return house[name] }, true, false)
+	if want := []string{"P2", "J1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("matching: dapat %v, ingin %v", got, want)
+	}
+}
+
+func TestMatchCompositionModes(t *testing.T) {
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	pools := []compPool{
+		{Name: "P", Cands: []Person{{Name: "P1", IsPenatua: true}, {Name: "P2", IsPenatua: true}}, Need: 1},
+		{Name: "J", Cands: []Person{{Name: "J1"}, {Name: "J2"}}, Need: 2},
+	}
+	none := func(string) bool { return false }
+	noHouse := func(string) string { return "" }
+	// P1 & J1 baru bertugas (tidak lolos prefer); J2 TidakBisa
+	pools[1].Cands[1].Unavailable = map[string]bool{d.Format("2006-01-02"): true}
+	prefer := func(name string) bool { return name != "P1" && name != "J1" }
+
+	cases := []struct {
+		name               string
+		strict, noRelaxB2B bool
+		want               []string
+	}{
+		{"relax", false, false, []string{"P2", "J1", "P1"}}, // slot J kedua diisi Penatua (relax-any)
+		{"strict", true, false, []string{"P2", "J1"}},
+		{"noRelaxB2B", false, true, []string{"P2"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := matchComposition(pools, d, prefer, map[string]bool{}, map[string]bool{}, none, noHouse, tc.strict, tc.noRelaxB2B)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("dapat %v, ingin %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// GenerateSchedule -optimal mengisi penuh kasus yang gagal dengan greedy untuk seed apa pun.
+func TestGenerateScheduleOptimal(t *testing.T) {
+	kolektan := map[string]bool{"kolektan": true}
+	maps := []RoleMap{
+		{Role: "Kolektan 1", SourceColumn: "Kolektan", Services: []string{"07"}},
+		{Role: "Kolektan 2", SourceColumn: "Kolektan", Services: []string{"07"}},
+	}
+	people := []Person{
+		{Name: "P1", IsPenatua: true, Household: "A", Marks: kolektan},
+		{Name: "P2", IsPenatua: true, Marks: kolektan},
+		{Name: "J1", Household: "A", Marks: kolektan},
+	}
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+
+	for seed := int64(1); seed <= 20; seed++ {
+		cfg := NewConfig([]time.Time{d})
+		cfg.Kolektan = Quota{Penatua: 1, Jemaat: 1}
+		cfg.StrictComposition = true
+		cfg.NoSameHousehold = true
+		cfg.StableOrder = true
+		cfg.Seed = seed
+		cfg.Optimal = true
+		assign, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := append(assign[d]["07"]["Kolektan 1"], assign[d]["07"]["Kolektan 2"]...)
+		if len(got) != 2 || got[0] == "P1" || got[1] == "P1" {
+			t.Errorf("seed %d: dapat %v, ingin P2 dan J1", seed, got)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package scheduler
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+func groupMappingsForService(maps []RoleMap, svc string) (map[string][]RoleMap, []RoleMap) {
+	groups := map[string][]RoleMap{}
+	var others []RoleMap
+	for _, m := range maps {
+		if !InService(m, svc) {
+			continue
+		}
+		if IsGroupRole(m.Role) {
+			base := BaseRole(m.Role)
+			groups[base] = append(groups[base], m)
+		} else {
+			others = append(others, m)
+		}
+	}
+	return groups, others
+}
+
+// spreadPicks: picked[i] ke baris rows[i], satu nama per baris ("" = baris
+// dibiarkan kosong). Baris dengan
+// label Role yang sama (mis. dua baris "Lektor") digabung di satu entri agar
+// nama kedua tidak menimpa yang pertama.
+func spreadPicks(slot map[string][]string, rows []RoleMap, picked []string) {
+	for _, rm := range rows {
+		slot[rm.Role] = []string{}
+	}
+	for i := range picked {
+		if i < len(rows) && picked[i] != "" {
+			slot[rows[i].Role] = append(slot[rows[i].Role], picked[i])
+		}
+	}
+}
+
+// planRows: rencana slot grup, satu per baris sampai need; label sama dijumlah.
+func planRows(plan SlotPlan, d time.Time, svc string, rows []RoleMap, need int) {
+	for role, c := range rowSlots(rows, need) {
+		plan.set(d, svc, role, c)
+	}
+}
+
+// fillUnit: satu langkah pengisian (satu role atau satu grup role) per ibadah.
+type fillUnit struct {
+	prio int
+	run  func()
+}
+
+// sortFillUnits: prioritas > 0 naik lebih dulu; tanpa prioritas tetap urutan asal di belakang.
+func sortFillUnits(units []fillUnit) {
+	sort.SliceStable(units, func(i, j int) bool {
+		a, b := units[i].prio, units[j].prio
+		if a > 0 && b > 0 {
+			return a < b
+		}
+		return a > 0 && b <= 0
+	})
+}
+
+// groupPriority: prioritas grup = Priority terkecil (> 0) di antara barisnya.
+func groupPriority(rows []RoleMap) int {
+	best := 0
+	for _, r := range rows {
+		if r.Priority > 0 && (best == 0 || r.Priority < best) {
+			best = r.Priority
+		}
+	}
+	return best
+}
+
+// compPool: satu kategori komposisi (mis. Penatua "P", Jemaat "J") beserta
+// kandidat terurut dan kuotanya. Kategori baru cukup ditambah sebagai pool.
+type compPool struct {
+	Name  string
+	Cands []Person
+	Need  int
+}
+
+// pickWithComposition mengisi kuota tiap pool berurutan dengan tahap yang sama:
+// prefer -> fallback (prefer) -> relax per pool -> relax-any gabungan.
+func pickWithComposition(
+	pools []compPool,
+	d time.Time,
+	rng *rand.Rand, // RNG tanggal d, untuk order
+	order func(*rand.Rand, []Person),
+	prefer func(string) bool,
+	already map[string]bool,
+	assignedAnyToday map[string]bool,
+	blocked func(string) bool, // batasan tambahan (mis. -noSameHousehold)
+	onPick func(name, stage string, pool int, sk *skipped), // dipanggil per pick (household, Trace)
+	strict bool, // tanpa relax-any (Step D)
+	noRelaxB2B bool, // tanpa relax per pool (Step C)
+	logf func(kind LogKind, format string, a ...any), // log pick verbose; nil = diam
+) []string {
+	totalNeed := 0
+	need := make([]int, len(pools))
+	for i, pl := range pools {
+		need[i] = pl.Need
+		totalNeed += pl.Need
+	}
+	picked := []string{}
+
+	used := map[string]bool{}
+
+	remaining := func(pool []Person) []Person {
+		res := []Person{}
+		for _, p := range pool {
+			if used[p.Name] || already[p.Name] || assignedAnyToday[p.Name] || IsUnavailable(p, d) || blocked(p.Name) {
+				continue
+			}
+			res = append(res, p)
+		}
+		return res
+	}
+
+	pickFrom := func(pool []Person, need *int, usePrefer bool, stage string) {
+		sk := &skipped{}
+		for _, p := range pool {
+			if len(picked) >= totalNeed {
+				break
+			}
+			if *need <= 0 {
+				break
+			}
+			if IsUnavailable(p, d) {
+				continue
+			}
+			if used[p.Name] || already[p.Name] || assignedAnyToday[p.Name] {
+				sk.add(skipAssigned, p.Name)
+				continue
+			}
+			if blocked(p.Name) {
+				sk.add(skipBlocked, p.Name)
+				continue
+			}
+			if usePrefer && !prefer(p.Name) {
+				sk.add(skipB2B, p.Name)
+				continue
+			}
+			picked = append(picked, p.Name)
+			used[p.Name] = true
+			already[p.Name] = true
+			assignedAnyToday[p.Name] = true
+			onPick(p.Name, stage, len(pool), sk)
+			*need--
+			if logf != nil {
+				switch {
+				case stage == "prefer":
+					logf(LogPick, "      pick %-20s\n", p.Name)
+				case strings.HasPrefix(stage, "relax"):
+					logf(LogRelax, "      pick(%s) %-20s\n", stage, p.Name)
+				default:
+					logf(LogPick, "      pick(%s) %-20s\n", stage, p.Name)
+				}
+			}
+		}
+	}
+
+	// Step A: penuhi kuota dengan prefer (anti back-to-back)
+	for i, pl := range pools {
+		pickFrom(pl.Cands, &need[i], true, "prefer")
+	}
+
+	// Step B: fallback tetap menjaga kuota per tipe (prefer masih dihormati)
+	for i, pl := range pools {
+		if need[i] > 0 {
+			pickFrom(remaining(pl.Cands), &need[i], true, "fallback-"+pl.Name)
+		}
+	}
+
+	// Step C: relax back-to-back per tipe (abaikan prefer) -> ONLY if noRelaxB2B OFF
+	if !noRelaxB2B {
+		for i, pl := range pools {
+			if need[i] > 0 {
+				pickFrom(remaining(pl.Cands), &need[i], false, "relax-"+pl.Name)
+			}
+		}
+	}
+
+	// Step D: kalau masih belum penuh totalNeed, isi apa saja (hanya jika tidak strict)
+	if !strict && len(picked) < totalNeed {
+		var merged []Person
+		for _, pl := range pools {
+			merged = append(merged, remaining(pl.Cands)...)
+		}
+		order(rng, merged)
+		extra := totalNeed - len(picked)
+		pickFrom(merged, &extra, false, "relax-any")
+	}
+
+	return picked
+}
+
+// bestAttempt menjalankan try(1..max) sampai ada percobaan tanpa slot kosong;
+// hasil: percobaan dengan kosong paling sedikit (yang pertama bila seri) dan
+// jumlah percobaan yang dipakai.
+func bestAttempt(max int, try func(attempt int) int) (best, used int) {
+	bestEmpty := -1
+	for a := 1; a <= max; a++ {
+		used = a
+		empty := try(a)
+		if bestEmpty < 0 || empty < bestEmpty {
+			best, bestEmpty = a, empty
+		}
+		if empty == 0 {
+			break
+		}
+	}
+	return best, used
+}
+
+// copySet / restoreSet: cadangan peta keadaan untuk percobaan pick yang
+// dibatalkan; restoreSet mengisi ulang peta yang sama (dipegang closure lain).
+func copySet(m map[string]bool) map[string]bool {
+	c := make(map[string]bool, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func restoreSet(m, snap map[string]bool) {
+	for k := range m {
+		if _, ok := snap[k]; !ok {
+			delete(m, k)
+		}
+	}
+	for k, v := range snap {
+		m[k] = v
+	}
+}
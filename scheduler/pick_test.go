@@ -0,0 +1,170 @@
+package scheduler
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPickWithCompositionModes(t *testing.T) {
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	// P1 baru bertugas (tidak lolos prefer); kuota minta 2 Penatua
+	pools := func() []compPool {
+		return []compPool{
+			{Name: "P", Cands: []Person{{Name: "P1", IsPenatua: true}}, Need: 2},
+			{Name: "J", Cands: []Person{{Name: "J1"}, {Name: "J2"}}, Need: 0},
+		}
+	}
+	prefer := func(name string) bool { return name != "P1" }
+	none := func(string) bool { return false }
+
+	cases := []struct {
+		name               string
+		strict, noRelaxB2B bool
+		want               []string
+	}{
+		{"relax", false, false, []string{"P1", "J1"}},
+		{"strict", true, false, []string{"P1"}},
+		{"noRelaxB2B", false, true, []string{"P1", "J1"}},
+		{"strict+noRelaxB2B", true, true, []string{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := pickWithComposition(pools(), d, nil, func(*rand.Rand, []Person) {}, prefer,
+				map[string]bool{}, map[string]bool{}, none, func(string, string, int, *skipped) {}, tc.strict, tc.noRelaxB2B, nil)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("dapat %v, ingin %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGenerateScheduleStrictComposition(t *testing.T) {
+	kolektan := map[string]bool{"kolektan": true}
+	maps := []RoleMap{
+		{Role: "Kolektan 1", SourceColumn: "Kolektan", Services: []string{"07"}},
+		{Role: "Kolektan 2", SourceColumn: "Kolektan", Services: []string{"07"}},
+	}
+	people := []Person{
+		{Name: "P1", IsPenatua: true, Marks: kolektan},
+		{Name: "J1", Marks: kolektan},
+	}
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+
+	for _, strict := range []bool{false, true} {
+		cfg := NewConfig([]time.Time{d})
+		cfg.Kolektan = Quota{Penatua: 2}
+		cfg.StrictComposition = strict
+		assign, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		filled := 0
+		for _, role := range []string{"Kolektan 1", "Kolektan 2"} {
+			filled += len(assign[d]["07"][role])
+		}
+		want := 2
+		if strict {
+			want = 1 // kuota Penatua hanya tercapai 1, sisa slot kosong
+		}
+		if filled != want {
+			t.Errorf("strict=%v: terisi %d slot, ingin %d", strict, filled, want)
+		}
+	}
+}
+
+// -maxAttempts: P1 serumah dengan satu-satunya Jemaat; bila P1 terpilih, slot
+// Jemaat kosong. Dengan percobaan ulang urutan lain (P2) harus ditemukan.
+func TestGenerateScheduleMaxAttempts(t *testing.T) {
+	kolektan := map[string]bool{"kolektan": true}
+	maps := []RoleMap{
+		{Role: "Kolektan 1", SourceColumn: "Kolektan", Services: []string{"07"}},
+		{Role: "Kolektan 2", SourceColumn: "Kolektan", Services: []string{"07"}},
+	}
+	people := []Person{
+		{Name: "P1", IsPenatua: true, Household: "A", Marks: kolektan},
+		{Name: "P2", IsPenatua: true, Marks: kolektan},
+		{Name: "J1", Household: "A", Marks: kolektan},
+	}
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+
+	filled := func(seed int64, attempts int) int {
+		cfg := NewConfig([]time.Time{d})
+		cfg.Kolektan = Quota{Penatua: 1, Jemaat: 1}
+		cfg.StrictComposition = true
+		cfg.NoSameHousehold = true
+		cfg.StableOrder = true
+		cfg.Seed = seed
+		cfg.MaxAttempts = attempts
+		assign, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(assign[d]["07"]["Kolektan 1"]) + len(assign[d]["07"]["Kolektan 2"])
+	}
+	unlucky := 0
+	for seed := int64(1); seed <= 20; seed++ {
+		if filled(seed, 1) < 2 {
+			unlucky++
+		}
+		if got := filled(seed, 10); got != 2 {
+			t.Errorf("seed %d, -maxAttempts 10: terisi %d slot, ingin 2", seed, got)
+		}
+	}
+	if unlucky == 0 {
+		t.Fatal("tidak ada seed yang gagal dengan satu percobaan; kasus uji tidak menguji apa-apa")
+	}
+}
+
+func TestBestAttempt(t *testing.T) {
+	empties := []int{3, 1, 2, 1}
+	best, used := bestAttempt(len(empties), func(a int) int { return empties[a-1] })
+	if best != 2 || used != 4 {
+		t.Errorf("dapat terbaik %d dari %d percobaan, ingin 2 dari 4", best, used)
+	}
+	best, used = bestAttempt(5, func(a int) int { return 2 - a })
+	if best != 2 || used != 2 {
+		t.Errorf("berhenti di percobaan tanpa kosong: dapat %d/%d, ingin 2/2", best, used)
+	}
+}
+
+func TestGenerateScheduleGroupRowsDistinct(t *testing.T) {
+	lektor := map[string]bool{"lektor": true}
+	people := []Person{{Name: "A", Marks: lektor}, {Name: "B", Marks: lektor}}
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name  string
+		roles []string
+	}{
+		{"bernomor", []string{"Lektor 1", "Lektor 2"}},
+		{"label sama", []string{"Lektor", "Lektor"}}, // tidak boleh saling menimpa
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var maps []RoleMap
+			for _, r := range tc.roles {
+				maps = append(maps, RoleMap{Role: r, SourceColumn: "Lektor", Services: []string{"07"}})
+			}
+			cfg := NewConfig([]time.Time{d})
+			cfg.MaxLektor = 2
+			assign, err := GenerateSchedule(cfg, people, maps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seen := map[string]bool{}
+			var got []string
+			for _, r := range tc.roles {
+				if seen[r] {
+					continue
+				}
+				seen[r] = true
+				got = append(got, assign[d]["07"][r]...)
+			}
+			if len(got) != 2 || got[0] == got[1] {
+				t.Fatalf("dapat %v, ingin dua nama berbeda", got)
+			}
+		})
+	}
+}
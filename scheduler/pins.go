@@ -0,0 +1,164 @@
+package scheduler
+
+import (
+	"sort"
+	"strings"
+)
+
+// Pin: satu penugasan tetap (-pin), ditempatkan sebelum pengisian acak;
+// slot lain role itu diisi seperti biasa di sekitarnya.
+type Pin struct {
+	Date    string // yyyy-mm-dd
+	Service string // kunci ibadah ("07", "10", ...)
+	Role    string // label MappingRole, atau role dasar grup (mis. "Lektor" untuk Lektor 1..n)
+	Name    string
+}
+
+// pinIndex: tanggal (yyyy-mm-dd) -> service -> label role -> nama, urut input.
+type pinIndex map[string]map[string]map[string][]string
+
+func (pi pinIndex) add(date, svc, role, name string) {
+	if pi[date] == nil {
+		pi[date] = map[string]map[string][]string{}
+	}
+	if pi[date][svc] == nil {
+		pi[date][svc] = map[string][]string{}
+	}
+	pi[date][svc][role] = append(pi[date][svc][role], name)
+}
+
+// resolvePins memetakan cfg.Pins ke label role MappingRole. Pin yang tidak
+// bisa dipakai (tanggal/role/ibadah tidak ada, orang tidak dikenal, tidak
+// eligible, TidakBisa, slot sudah penuh) diberi WARN lalu dilewati.
+func resolvePins(cfg *Config, people []Person, maps []RoleMap) pinIndex {
+	pi := pinIndex{}
+	if len(cfg.Pins) == 0 {
+		return pi
+	}
+	locked := lockedByDate(cfg.Locked)
+	dateIdx := map[string]int{}
+	for i, d := range cfg.Dates {
+		dateIdx[d.Format("2006-01-02")] = i
+	}
+	personIdx := map[string]Person{}
+	for _, p := range people {
+		personIdx[NormKey(p.Name)] = p
+	}
+	warn := func(p Pin, why string) {
+		cfg.logk(LogWarn, "WARN: -pin %s:%s:%s=%s dilewati: %s\n", p.Date, p.Service, p.Role, p.Name, why)
+	}
+
+	for _, p := range cfg.Pins {
+		di, ok := dateIdx[p.Date]
+		if !ok {
+			warn(p, "tanggal tidak ada di jadwal")
+			continue
+		}
+		d := cfg.Dates[di]
+		if _, ok := locked[p.Date]; ok {
+			warn(p, "tanggal terkunci (-lockDates)")
+			continue
+		}
+		person, ok := personIdx[NormKey(p.Name)]
+		if !ok {
+			warn(p, "nama tidak ada di sheet Petugas")
+			continue
+		}
+		if IsUnavailable(person, d) {
+			warn(p, "orang ini TidakBisa di tanggal itu")
+			continue
+		}
+		if svcs := pi[p.Date]; svcs != nil {
+			dup := false
+			for _, names := range svcs[p.Service] {
+				dup = dup || containsName(names, person.Name)
+			}
+			if dup {
+				warn(p, "orang ini sudah di-pin di ibadah yang sama")
+				continue
+			}
+		}
+
+		// label persis dulu; role dasar grup ("Lektor") -> baris grup pertama yang masih kosong
+		var rows []RoleMap
+		for _, m := range maps {
+			if InService(m, p.Service) && strings.EqualFold(m.Role, strings.TrimSpace(p.Role)) {
+				rows = append(rows, m)
+			}
+		}
+		if len(rows) == 0 && IsGroupRole(p.Role) {
+			for _, m := range maps {
+				if InService(m, p.Service) && BaseRole(m.Role) == BaseRole(p.Role) {
+					rows = append(rows, m)
+				}
+			}
+		}
+		if len(rows) == 0 {
+			warn(p, "role tidak ada di MappingRole untuk ibadah "+p.Service)
+			continue
+		}
+		m := rows[0]
+		if IsMajelisPendamping(m.Role) && !person.IsPenatua {
+			warn(p, "Majelis Pendamping wajib Penatua")
+			continue
+		}
+		if !person.Marks[NormKey(m.SourceColumn)] {
+			warn(p, "tidak bertanda di kolom "+m.SourceColumn)
+			continue
+		}
+		placed := false
+		for _, rm := range rows {
+			if len(pi[p.Date][p.Service][rm.Role]) < cfg.SlotsFor(rm.Role, p.Service, d, maps) {
+				pi.add(p.Date, p.Service, rm.Role, person.Name)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			warn(p, "slot role sudah penuh atau tidak diminta di ibadah ini")
+		}
+	}
+	return pi
+}
+
+// sortedPinRoles: label role pin di satu ibadah, terurut (urutan penempatan stabil).
+func sortedPinRoles(pinned map[string][]string) []string {
+	roles := make([]string, 0, len(pinned))
+	for r := range pinned {
+		roles = append(roles, r)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// pinRows: baris grup ke-1..need yang diisi pin (indeks -> nama) dan indeks
+// baris bebas untuk picker, urut baris.
+func pinRows(rows []RoleMap, need int, pinned map[string][]string) (fixed map[int]string, free []int) {
+	fixed = map[int]string{}
+	used := map[string]int{}
+	for i := 0; i < need && i < len(rows); i++ {
+		label := rows[i].Role
+		if used[label] < len(pinned[label]) {
+			fixed[i] = pinned[label][used[label]]
+			used[label]++
+			continue
+		}
+		free = append(free, i)
+	}
+	return fixed, free
+}
+
+// mergeRows: satu nama per baris grup (kosong = tidak terisi) dari pin dan
+// hasil picker (picked[k] ke baris free[k]), untuk spreadPicks.
+func mergeRows(rows []RoleMap, fixed map[int]string, free []int, picked []string) []string {
+	out := make([]string, len(rows))
+	for i, n := range fixed {
+		out[i] = n
+	}
+	for k, n := range picked {
+		if k < len(free) {
+			out[free[k]] = n
+		}
+	}
+	return out
+}
@@ -0,0 +1,250 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ServiceKeys: kunci base (urutan dipertahankan) lalu kunci lain di MappingRole,
+// terurut. Tanpa base: default 07 & 10 digabung kunci MappingRole, terurut.
+// Role "both" (Services kosong) berlaku di semua kunci ini.
+func ServiceKeys(maps []RoleMap, base []string) []string {
+	set := map[string]bool{}
+	keys := append([]string(nil), base...)
+	if len(base) == 0 {
+		keys = []string{"07", "10"}
+	}
+	for _, k := range keys {
+		set[k] = true
+	}
+	var extra []string
+	for _, m := range maps {
+		for _, svc := range m.Services {
+			if !set[svc] {
+				set[svc] = true
+				extra = append(extra, svc)
+			}
+		}
+	}
+	keys = append(keys, extra...)
+	if len(base) == 0 {
+		sort.Strings(keys)
+	} else {
+		sort.Strings(keys[len(base):])
+	}
+	return keys
+}
+
+// InService: role m berlaku di ibadah svc (Services kosong = semua ibadah).
+func InService(m RoleMap, svc string) bool {
+	if len(m.Services) == 0 {
+		return true
+	}
+	for _, s := range m.Services {
+		if s == svc {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleCooldown: cooldown untuk satu unit pengisian (role atau grup role):
+// CooldownWeeks terbesar yang diisi di rows, selain itu def.
+func RoleCooldown(rows []RoleMap, def int) int {
+	n, set := 0, false
+	for _, m := range rows {
+		if m.CooldownWeeks != nil && (!set || *m.CooldownWeeks > n) {
+			n, set = *m.CooldownWeeks, true
+		}
+	}
+	if !set {
+		return def
+	}
+	return n
+}
+
+func BaseRole(role string) string {
+	r := strings.ToLower(strings.TrimSpace(role))
+	if strings.HasPrefix(r, "lektor") {
+		return "lektor"
+	}
+	if strings.HasPrefix(r, "prokantor") {
+		return "prokantor"
+	}
+	if strings.HasPrefix(r, "pemusik") {
+		return "pemusik"
+	}
+	if strings.HasPrefix(r, "kolektan") {
+		return "kolektan"
+	}
+	if strings.Contains(r, "pjemaat") || strings.Contains(r, "p. jemaat") {
+		return "pjemaat"
+	}
+	return r
+}
+
+// IsGroupRole: role yang diisi per grup lintas barisnya (Kolektan, P. Jemaat,
+// Lektor, Prokantor, Pemusik).
+func IsGroupRole(role string) bool {
+	switch BaseRole(role) {
+	case "lektor", "prokantor", "pemusik", "kolektan", "pjemaat":
+		return true
+	}
+	return false
+}
+
+func IsMajelisPendamping(role string) bool {
+	r := strings.ToLower(role)
+	return strings.Contains(r, "majel") && strings.Contains(r, "pend")
+}
+
+func defaultSlotsForRole(role, svc string, maxLektor, maxPro, maxMus int) int {
+	low := strings.ToLower(strings.TrimSpace(role))
+	if strings.Contains(low, "lektor") {
+		return maxLektor
+	}
+	if strings.Contains(low, "prokantor") {
+		return maxPro
+	}
+	if strings.Contains(low, "pemusik") {
+		return maxMus
+	}
+	return 1
+}
+
+func ParsePattern(code string) (penatua, jemaat, total int, err error) {
+	c := strings.ToLower(strings.TrimSpace(code))
+	if len(c) < 2 {
+		return 0, 0, 0, fmt.Errorf("kode '%s' tidak valid", code)
+	}
+	var n int
+	var suf string
+	if _, e := fmt.Sscanf(c, "%d%s", &n, &suf); e != nil {
+		return 0, 0, 0, fmt.Errorf("kode '%s' tidak valid", code)
+	}
+	if n < 1 || n > 4 {
+		return 0, 0, 0, fmt.Errorf("jumlah '%d' di luar batas 1..4", n)
+	}
+	switch n {
+	case 1:
+		if suf == "a" {
+			return 1, 0, 1, nil
+		}
+		if suf == "b" {
+			return 0, 1, 1, nil
+		}
+	case 2:
+		switch suf {
+		case "a":
+			return 1, 1, 2, nil
+		case "b":
+			return 2, 0, 2, nil
+		case "c":
+			return 0, 2, 2, nil
+		}
+	case 3:
+		switch suf {
+		case "a":
+			return 1, 2, 3, nil
+		case "b":
+			return 2, 1, 3, nil
+		case "c":
+			return 3, 0, 3, nil
+		case "d":
+			return 0, 3, 3, nil
+		}
+	case 4:
+		switch suf {
+		case "a":
+			return 1, 3, 4, nil
+		case "b":
+			return 2, 2, 4, nil
+		case "c":
+			return 3, 1, 4, nil
+		case "d":
+			return 4, 0, 4, nil
+		case "e":
+			return 0, 4, 4, nil
+		}
+	}
+	return 0, 0, 0, fmt.Errorf("kode '%s' tidak dikenali", code)
+}
+
+// BuildAvoidIndex: relasi Hindari dibuat simetris, cukup ditulis di salah satu sisi.
+func BuildAvoidIndex(people []Person) map[string]map[string]bool {
+	idx := map[string]map[string]bool{}
+	add := func(a, b string) {
+		if idx[a] == nil {
+			idx[a] = map[string]bool{}
+		}
+		idx[a][b] = true
+	}
+	for _, p := range people {
+		for _, other := range p.Avoid {
+			if other == p.Name {
+				continue
+			}
+			add(p.Name, other)
+			add(other, p.Name)
+		}
+	}
+	return idx
+}
+
+// avoidConflict: true bila ada orang di assigned yang ada di daftar Hindari name.
+func avoidConflict(idx map[string]map[string]bool, name string, assigned map[string]bool) bool {
+	for other := range idx[name] {
+		if assigned[other] {
+			return true
+		}
+	}
+	return false
+}
+
+func containsName(list []string, name string) bool {
+	for _, n := range list {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func uniq(in []string) []string {
+	m := map[string]struct{}{}
+	var res []string
+	for _, s := range in {
+		if _, ok := m[s]; ok {
+			continue
+		}
+		m[s] = struct{}{}
+		res = append(res, s)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// helper to quiet unused var warnings in format string above
+func jemaatNames(in []string) []string { return in }
+
+func SameDay(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// NormKey: kunci pembanding header/nama: huruf kecil, spasi dirapatkan.
+// BOM & karakter zero-width (sering terbawa ekspor Excel) dibuang; NBSP
+// dan spasi Unicode lain dihitung spasi biasa.
+func NormKey(s string) string {
+	s = strings.Map(func(r rune) rune {
+		switch r {
+		case '\ufeff', '\u200b', '\u200c', '\u200d', '\u2060':
+			return -1
+		}
+		return r
+	}, s)
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
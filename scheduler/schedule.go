@@ -0,0 +1,144 @@
+package scheduler
+
+import (
+	"sort"
+	"time"
+)
+
+// Schedule: bentuk terurut Assignment berbasis slice untuk writer & exporter.
+// Tanggal mengikuti urutan dates, ibadah mengikuti urutan dari pemanggil, role
+// mengikuti urutan MappingRole (role di luar urutan itu menyusul, abjad), jadi
+// iterasi selalu deterministik tanpa sort ulang per akses.
+type Schedule struct {
+	Days []ScheduleDay
+}
+
+// ScheduleDay: semua ibadah pada satu tanggal.
+type ScheduleDay struct {
+	Date     time.Time
+	Services []ServiceRoles
+}
+
+// ServiceRoles: role & petugas satu ibadah, urut MappingRole.
+type ServiceRoles struct {
+	Service string
+	Roles   []RoleNames
+}
+
+// RoleNames: petugas satu role (kosong = slot tanpa petugas) dan cadangannya.
+type RoleNames struct {
+	Role    string
+	Names   []string
+	Backups []string // Config.Substitutes; nil = tanpa cadangan
+}
+
+// NewSchedule menyusun Schedule dari Assignment. backups (boleh nil) =
+// Config.Backups; roleOrder = urutan role (MappingRole); serviceOrder
+// mengurutkan kunci ibadah satu tanggal.
+// Slice nama dipakai bersama dengan assign, tidak disalin.
+func NewSchedule(assign, backups Assignment, dates []time.Time, roleOrder []string,
+	serviceOrder func(map[string]map[string][]string) []string) Schedule {
+	rank := make(map[string]int, len(roleOrder))
+	for i, r := range roleOrder {
+		if _, ok := rank[r]; !ok {
+			rank[r] = i
+		}
+	}
+	s := Schedule{Days: make([]ScheduleDay, len(dates))}
+	for i, d := range dates {
+		day := assign[d]
+		svcs := serviceOrder(day)
+		total := 0
+		for _, svc := range svcs {
+			total += len(day[svc])
+		}
+		// satu backing array role per tanggal
+		all := make([]RoleNames, 0, total)
+		s.Days[i] = ScheduleDay{Date: d, Services: make([]ServiceRoles, len(svcs))}
+		for j, svc := range svcs {
+			start := len(all)
+			for role, names := range day[svc] {
+				all = append(all, RoleNames{Role: role, Names: names, Backups: backups[d][svc][role]})
+			}
+			roles := all[start:len(all):len(all)]
+			sort.Slice(roles, func(a, b int) bool {
+				ra, oka := rank[roles[a].Role]
+				rb, okb := rank[roles[b].Role]
+				switch {
+				case oka && okb:
+					return ra < rb
+				case oka != okb:
+					return oka
+				}
+				return roles[a].Role < roles[b].Role
+			})
+			s.Days[i].Services[j] = ServiceRoles{Service: svc, Roles: roles}
+		}
+	}
+	return s
+}
+
+// HasBackups: true bila ada minimal satu cadangan (Config.Substitutes).
+func (s Schedule) HasBackups() bool {
+	for _, d := range s.Days {
+		for _, svc := range d.Services {
+			for _, r := range svc.Roles {
+				if len(r.Backups) > 0 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Service mengembalikan ibadah svc pada tanggal ini, nil bila tidak ada.
+func (d *ScheduleDay) Service(svc string) *ServiceRoles {
+	for i := range d.Services {
+		if d.Services[i].Service == svc {
+			return &d.Services[i]
+		}
+	}
+	return nil
+}
+
+// Names mengembalikan petugas role di ibadah svc (nil bila tidak ada).
+func (d *ScheduleDay) Names(svc, role string) []string {
+	if s := d.Service(svc); s != nil {
+		return s.Names(role)
+	}
+	return nil
+}
+
+// Names mengembalikan petugas role di ibadah ini (nil bila tidak ada).
+func (s *ServiceRoles) Names(role string) []string {
+	return s.Role(role).Names
+}
+
+// Role mengembalikan entri role di ibadah ini (nilai nol bila tidak ada).
+func (s *ServiceRoles) Role(role string) RoleNames {
+	for _, r := range s.Roles {
+		if r.Role == role {
+			return r
+		}
+	}
+	return RoleNames{}
+}
+
+// Assignment mengembalikan bentuk map lama (untuk kode yang belum pindah),
+// tanpa cadangan.
+func (s Schedule) Assignment() Assignment {
+	out := make(Assignment, len(s.Days))
+	for _, d := range s.Days {
+		day := make(map[string]map[string][]string, len(d.Services))
+		for _, svc := range d.Services {
+			roles := make(map[string][]string, len(svc.Roles))
+			for _, r := range svc.Roles {
+				roles[r.Role] = r.Names
+			}
+			day[svc.Service] = roles
+		}
+		out[d.Date] = day
+	}
+	return out
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// NewSchedule: role urut MappingRole (sisanya abjad), ibadah urut pemanggil,
+// dan Assignment() kembali ke bentuk map yang sama.
+func TestNewSchedule(t *testing.T) {
+	d1 := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC)
+	assign := Assignment{
+		d1: {
+			"10": {"Pemusik": {"C"}, "Lektor 1": {"A"}, "Zeta": nil, "Alfa": {"X"}},
+			"07": {"Multimedia": {"B"}, "Lektor 1": {"D", "E"}},
+		},
+		d2: {"07": {}},
+	}
+	order := []string{"Lektor 1", "Multimedia", "Pemusik", "Lektor 1"}
+	svcOrder := func(day map[string]map[string][]string) []string {
+		var keys []string
+		for k := range day {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		return keys
+	}
+	s := NewSchedule(assign, nil, []time.Time{d1, d2}, order, svcOrder)
+
+	var got []string
+	for _, day := range s.Days {
+		for _, sv := range day.Services {
+			for _, r := range sv.Roles {
+				got = append(got, day.Date.Format("02")+" "+sv.Service+" "+r.Role)
+			}
+		}
+	}
+	want := []string{
+		"03 07 Lektor 1", "03 07 Multimedia",
+		"03 10 Lektor 1", "03 10 Pemusik", "03 10 Alfa", "03 10 Zeta",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("urutan = %v, mau %v", got, want)
+	}
+	if len(s.Days[1].Services) != 1 || len(s.Days[1].Services[0].Roles) != 0 {
+		t.Fatalf("tanggal 10: %+v", s.Days[1])
+	}
+
+	if n := s.Days[0].Names("07", "Lektor 1"); !reflect.DeepEqual(n, []string{"D", "E"}) {
+		t.Errorf("Names(07, Lektor 1) = %v", n)
+	}
+	if n := s.Days[0].Names("07", "Pemusik"); n != nil {
+		t.Errorf("Names(07, Pemusik) = %v, mau nil", n)
+	}
+	if n := s.Days[0].Names("17", "Lektor 1"); n != nil {
+		t.Errorf("Names(17, Lektor 1) = %v, mau nil", n)
+	}
+	if back := s.Assignment(); !reflect.DeepEqual(back, assign) {
+		t.Errorf("Assignment() = %v, mau %v", back, assign)
+	}
+}
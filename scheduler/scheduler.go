@@ -0,0 +1,1112 @@
+// Package scheduler berisi mesin penjadwalan petugas ibadah tanpa
+// ketergantungan ke CLI: semua pengaturan (yang di CLI berupa flag)
+// dikirim lewat Config, sehingga bisa dipakai dari program Go lain.
+//
+//	cfg := scheduler.NewConfig(dates)
+//	cfg.Fair = true
+//	assign, err := scheduler.GenerateSchedule(cfg, people, maps)
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ==================== Types ====================
+
+type RoleMap struct {
+	Role         string
+	SourceColumn string
+	Services     []string       // kunci ibadah, mis. ["07"] atau ["07","17"]; kosong = semua ("both")
+	Slots        map[string]int // service -> jumlah slot (kolom Slots07, Slots10, Slots17, ...)
+	Priority     int            // urutan pengisian (kolom Priority, 1 = paling awal); 0 = urutan bawaan
+	// CooldownWeeks: jeda anti-B2B khusus role ini (kolom CooldownWeeks);
+	// nil = Config.CooldownWeeks, 0 = boleh bertugas tiap Minggu.
+	CooldownWeeks *int
+	// SamePerson: orang yang sama di semua ibadah role ini (kolom SamePerson);
+	// dipilih di ibadah pertama lalu disalin ke ibadah berikutnya.
+	SamePerson bool
+	// CrossService: boleh/tidak bertugas di role ini bila orangnya sudah
+	// bertugas di ibadah lain pada tanggal yang sama (kolom CrossService).
+	CrossService CrossRule
+}
+
+// CrossRule: aturan lintas ibadah per role.
+type CrossRule int
+
+const (
+	CrossDefault CrossRule = iota // bawaan: diblokir, kecuali relax Majelis Pendamping
+	CrossAllow                    // boleh (tetap tidak dua role di ibadah yang sama)
+	CrossDeny                     // dilarang, termasuk relax Majelis Pendamping
+)
+
+type Person struct {
+	Name        string
+	IsPenatua   bool
+	Marks       map[string]bool // normalized header -> eligible
+	Weights     map[string]int  // normalized header -> bobot (1 = biasa, 2+ = diutamakan, 0 = tidak eligible)
+	Unavailable map[string]bool // "yyyy-mm-dd" atau "d" (tanggal saja) -> tidak bisa bertugas
+	Household   string          // ID keluarga (kolom Keluarga), kosong = tidak ada
+	Partner     string          // nama pasangan bertugas (kolom Pasangan), kosong = tidak ada
+	Avoid       []string        // nama yang tidak boleh satu ibadah (kolom Hindari)
+}
+
+type Assignment = map[time.Time]map[string]map[string][]string // date -> service -> role -> names
+
+// Quota: jumlah Penatua & Jemaat untuk grup komposisi (Kolektan, P. Jemaat).
+type Quota struct {
+	Penatua int
+	Jemaat  int
+}
+
+// QuotaFromPattern mengubah kode pola (1a..4e) menjadi Quota.
+func QuotaFromPattern(code string) (Quota, error) {
+	p, j, _, err := ParsePattern(code)
+	if err != nil {
+		return Quota{}, err
+	}
+	return Quota{Penatua: p, Jemaat: j}, nil
+}
+
+// LogKind: jenis baris log scheduler, agar pemanggil bisa menghias baris
+// (Config.Paint) tanpa membaca teks pesannya.
+type LogKind int
+
+const (
+	LogPlain LogKind = iota // info & progres
+	LogPick                 // petugas terpilih sesuai aturan
+	LogRelax                // petugas terpilih dengan aturan dilonggarkan (relax)
+	LogEmpty                // slot dibiarkan kosong
+	LogWarn                 // WARN
+)
+
+// Config: semua pengaturan generate. Nilai nol berarti fitur nonaktif,
+// kecuali batas Lektor/Prokantor/Pemusik (0 = role tidak diisi); pakai
+// NewConfig untuk default yang sama dengan CLI.
+type Config struct {
+	Dates    []time.Time            // tanggal ibadah yang dijadwalkan, terurut
+	Services []string               // kunci ibadah urut jam; kosong = 07 & 10 (plus kunci lain di MappingRole)
+	Prior    map[string][]time.Time // opsional: nama -> tanggal bertugas sebelum Dates[0]
+
+	MaxLektor     int
+	MaxProkantor  int
+	MaxPemusik    int
+	CooldownWeeks int // hindari yang bertugas dalam N Minggu terjadwal sebelumnya
+	// CooldownPerRole: cooldown dihitung per role dasar (Kolektan minggu lalu
+	// boleh Lektor minggu ini). Prior tanpa info role tetap berlaku untuk semua role.
+	CooldownPerRole bool
+	MaxPerPerson    int // batas total tugas per orang (0 = tanpa batas)
+
+	MaxOn map[string]map[string]int // "lektor"/"prokantor"/"pemusik" -> service -> batas khusus ibadah itu (menimpa MaxLektor dst)
+	Pins  []Pin                     // penugasan tetap (-pin), ditempatkan sebelum pengisian acak
+
+	// Locked: tanggal terkunci (-lockDates) beserta isinya, dipakai apa adanya.
+	// Petugasnya tetap dihitung untuk cooldown tanggal sebelum & sesudahnya.
+	Locked Assignment
+	// Kept: isi role yang tidak di-generate (mis. -roles dengan -merge), per
+	// tanggal. Petugasnya dihitung sudah bertugas di ibadah itu (Hindari,
+	// keluarga, lintas ibadah, cooldown) tetapi tidak ikut hasil.
+	Kept Assignment
+
+	Kolektan Quota
+	PJemaat  Quota
+	QuotaOn  map[string]map[string]Quota // "kolektan"/"pjemaat" -> "yyyy-mm-dd" -> kuota khusus tanggal itu
+
+	StrictComposition bool    // kuota P/J tidak tercapai -> sisa slot kosong (tanpa relax-any)
+	NoRelaxB2B        bool    // anti back-to-back wajib, tanpa fase relax
+	MaxAttempts       int     // dengan StrictComposition: maks percobaan urutan komposisi per tanggal (<=1 = sekali)
+	Optimal           bool    // komposisi greedy kurang penuh -> bipartite matching (matchComposition)
+	Fair              bool    // utamakan yang paling sedikit bertugas pada run ini
+	Bias              float64 // 0..1: campuran urutan acak (0) dan paling sedikit bertugas dulu (1); diabaikan bila Fair
+	StableOrder       bool    // urutan kandidat dari hash (Seed, tanggal, role, nama), bukan math/rand
+	Seed              int64   // sumber acak; tiap tanggal memakai RNG turunan (Seed, tanggal)
+	NoSameHousehold   bool    // satu ID Keluarga maksimal satu orang per ibadah
+	NoCrossService    bool    // satu orang maksimal satu ibadah per tanggal
+
+	Verbose bool
+	Log     io.Writer // tujuan WARN & log verbose; nil = dibuang
+	// Paint: opsional, menghias satu baris log menurut jenisnya (mis. warna
+	// terminal); nil = apa adanya
+	Paint func(kind LogKind, line string) string
+
+	Plan  SlotPlan // opsional: diisi jumlah slot yang diminta per role (untuk laporan kekurangan)
+	Trace *Trace   // opsional: diisi alasan setiap pick (tahap, ukuran pool, kandidat yang dilewati)
+
+	// Substitutes: jumlah cadangan per role per ibadah, diisi ke Backups
+	// (opsional, nil = tanpa cadangan). Cadangan tidak dihitung ke
+	// -maxPerPerson, -fair, maupun cooldown.
+	Substitutes int
+	Backups     Assignment
+}
+
+// NewConfig mengembalikan Config dengan default yang sama seperti CLI
+// (Lektor/Prokantor/Pemusik 2, cooldown 1, Kolektan 2b, P. Jemaat 3a).
+func NewConfig(dates []time.Time) Config {
+	return Config{
+		Dates:         dates,
+		MaxLektor:     2,
+		MaxProkantor:  2,
+		MaxPemusik:    2,
+		CooldownWeeks: 1,
+		Kolektan:      Quota{Penatua: 2},
+		PJemaat:       Quota{Penatua: 1, Jemaat: 2},
+	}
+}
+
+func (c *Config) logf(format string, a ...any) {
+	c.logk(LogPlain, format, a...)
+}
+
+// logk menulis baris log berjenis kind; Config.Paint (bila ada) menghiasnya.
+func (c *Config) logk(kind LogKind, format string, a ...any) {
+	if c.Log == nil {
+		return
+	}
+	s := fmt.Sprintf(format, a...)
+	if c.Paint != nil {
+		s = c.Paint(kind, s)
+	}
+	io.WriteString(c.Log, s)
+}
+
+// SlotPlan mencatat jumlah slot yang diminta GenerateSchedule per
+// tanggal -> service -> role, dengan hitungan slot yang sama persis.
+type SlotPlan map[time.Time]map[string]map[string]int
+
+func (p SlotPlan) set(d time.Time, svc, role string, n int) {
+	if p == nil {
+		return
+	}
+	if p[d] == nil {
+		p[d] = map[string]map[string]int{}
+	}
+	if p[d][svc] == nil {
+		p[d][svc] = map[string]int{}
+	}
+	p[d][svc][role] = n
+}
+
+// ==================== GenerateSchedule() ====================
+
+// GenerateSchedule mengisi semua role di MappingRole (maps) untuk setiap
+// tanggal cfg.Dates dari daftar petugas (people). Cfg.Prior (opsional) menjaga
+// cooldown tetap berlaku di pergantian bulan.
+func GenerateSchedule(cfg Config, people []Person, maps []RoleMap) (Assignment, error) {
+	if len(cfg.Dates) == 0 {
+		return nil, errors.New("tidak ada tanggal untuk dijadwalkan")
+	}
+	assign := Assignment{}
+	dates, prior, plan := cfg.Dates, cfg.Prior, cfg.Plan
+	cooldown := cfg.CooldownWeeks
+	verbose := cfg.Verbose
+	strict, noRelaxB2B := cfg.StrictComposition, cfg.NoRelaxB2B
+	var pickLog func(LogKind, string, ...any)
+	if verbose {
+		pickLog = cfg.logk
+	}
+
+	// tanggal-tanggal bertugas per orang (untuk cooldown N Minggu)
+	servedDates := map[string][]time.Time{}
+	// urutan Minggu terjadwal: tanggal prior (unik, urut) lalu dates
+	var scheduled []time.Time
+	priorSeen := map[string]bool{}
+	for name, ds := range prior {
+		for _, t := range ds {
+			if len(dates) > 0 && !t.Before(dates[0]) {
+				continue
+			}
+			servedDates[name] = append(servedDates[name], t)
+			if k := t.Format("2006-01-02"); !priorSeen[k] {
+				priorSeen[k] = true
+				scheduled = append(scheduled, t)
+			}
+		}
+		sort.Slice(servedDates[name], func(i, j int) bool { return servedDates[name][i].Before(servedDates[name][j]) })
+	}
+	sort.Slice(scheduled, func(i, j int) bool { return scheduled[i].Before(scheduled[j]) })
+	offset := len(scheduled)
+	scheduled = append(scheduled, dates...)
+	// -cooldownPerRole: tanggal prior saja + tanggal run ini per nama -> role dasar
+	priorDates := map[string][]time.Time{}
+	servedRole := map[string]map[string][]time.Time{}
+	if cfg.CooldownPerRole {
+		for name, ds := range servedDates {
+			priorDates[name] = append([]time.Time(nil), ds...)
+		}
+	}
+
+	// jumlah penugasan per orang pada run ini (untuk -fair)
+	assignCount := map[string]int{}
+	markServed := func(name string, d time.Time, role string) {
+		assignCount[name]++
+		if cfg.CooldownPerRole {
+			if servedRole[name] == nil {
+				servedRole[name] = map[string][]time.Time{}
+			}
+			key := BaseRole(role)
+			if rs := servedRole[name][key]; len(rs) == 0 || !SameDay(rs[len(rs)-1], d) {
+				servedRole[name][key] = append(rs, d)
+			}
+		}
+		ds := servedDates[name]
+		if len(ds) > 0 && SameDay(ds[len(ds)-1], d) {
+			return
+		}
+		servedDates[name] = append(ds, d)
+	}
+
+	// bobot eligibility per orang per kolom Petugas
+	weightIdx := map[string]map[string]int{}
+	for _, p := range people {
+		weightIdx[p.Name] = p.Weights
+	}
+
+	// Urutan kandidat: acak (atau -stableOrder), lalu dengan -fair diurutkan
+	// stabil berdasarkan jumlah tugas terkecil sehingga RNG hanya pemecah seri;
+	// dengan Bias diurutkan menurut skor campuran (biasScores).
+	// Terakhir bobot kolom src (tertinggi dulu); tanpa bobot >1 urutan tidak berubah.
+	// rng = RNG tanggal d (dateRand), dioper eksplisit per tanggal
+	orderNames := func(rng *rand.Rand, names []string, d time.Time, key, src string) {
+		shuffleNames(&cfg, rng, names, d, key)
+		if cfg.Fair {
+			sort.SliceStable(names, func(i, j int) bool { return assignCount[names[i]] < assignCount[names[j]] })
+		} else if cfg.Bias > 0 {
+			score := biasScores(names, cfg.Bias, assignCount)
+			sort.SliceStable(names, func(i, j int) bool { return score[names[i]] < score[names[j]] })
+		}
+		col := NormKey(src)
+		sort.SliceStable(names, func(i, j int) bool { return weightIdx[names[i]][col] > weightIdx[names[j]][col] })
+	}
+	orderPeople := func(rng *rand.Rand, ps []Person, d time.Time, key, src string) {
+		shufflePeople(&cfg, rng, ps, d, key)
+		if cfg.Fair {
+			sort.SliceStable(ps, func(i, j int) bool { return assignCount[ps[i].Name] < assignCount[ps[j].Name] })
+		} else if cfg.Bias > 0 {
+			names := make([]string, len(ps))
+			for i, p := range ps {
+				names[i] = p.Name
+			}
+			score := biasScores(names, cfg.Bias, assignCount)
+			sort.SliceStable(ps, func(i, j int) bool { return score[ps[i].Name] < score[ps[j].Name] })
+		}
+		col := NormKey(src)
+		sort.SliceStable(ps, func(i, j int) bool { return weightIdx[ps[i].Name][col] > weightIdx[ps[j].Name][col] })
+	}
+
+	// -maxPerPerson: buang kandidat yang sudah mencapai batas bulanan
+	maxPer := cfg.MaxPerPerson
+	withinCap := func(names []string) ([]string, int) {
+		if maxPer <= 0 {
+			return names, 0
+		}
+		kept := names[:0]
+		for _, n := range names {
+			if assignCount[n] < maxPer {
+				kept = append(kept, n)
+			}
+		}
+		return kept, len(names) - len(kept)
+	}
+	capWarn := func(d time.Time, svc, role string, dropped, missing int) {
+		if dropped > 0 && missing > 0 {
+			cfg.logk(LogEmpty, "WARN: %s %s (%s.00) kosong %d slot karena batas -maxPerPerson=%d\n",
+				d.Format("02-01-2006"), role, svc, missing, maxPer)
+		}
+	}
+
+	poolWarned := map[string]bool{}
+	services := ServiceKeys(maps, cfg.Services)
+	// MP berstatus "both" diisi di ibadah terakhir (dulu: hanya 10.00)
+	lastSvc := services[len(services)-1]
+
+	// index Penatua untuk rekap cepat & tanggal TidakBisa per orang
+	penIdx := map[string]bool{}
+	unavailIdx := map[string]map[string]bool{}
+	householdIdx := map[string]string{}
+	partnerIdx := map[string]string{}
+	avoidIdx := BuildAvoidIndex(people)
+	for _, p := range people {
+		penIdx[p.Name] = p.IsPenatua
+		unavailIdx[p.Name] = p.Unavailable
+		householdIdx[p.Name] = p.Household
+		partnerIdx[p.Name] = p.Partner
+	}
+
+	pins := resolvePins(&cfg, people, maps)
+	locked := lockedByDate(cfg.Locked)
+	// tanggal terkunci per orang: dicek juga ke depan, agar tanggal sebelum
+	// tanggal terkunci tidak membuat back-to-back dengannya
+	lockedOn := lockedServed(dates, locked)
+	kept := lockedByDate(cfg.Kept)
+	for n, ds := range lockedServed(dates, kept) {
+		lockedOn[n] = append(lockedOn[n], ds...)
+	}
+
+	// cadangan tanggal d: setelah semua slot terisi, per role (urut MappingRole)
+	// paling banyak Substitutes orang yang eligible, tersedia, belum bertugas
+	// maupun jadi cadangan hari itu, dan lolos Hindari/keluarga (backupRules)
+	// terhadap petugas ibadahnya, termasuk pin dan Kept
+	rules := newBackupRules(people, cfg.NoSameHousehold)
+	pickBackups := func(rng *rand.Rand, d time.Time) {
+		used, bySvc := servingOn(assign[d], kept[d.Format("2006-01-02")])
+		day := map[string]map[string][]string{}
+		for _, svc := range services {
+			day[svc] = map[string][]string{}
+			for _, m := range maps {
+				if _, ok := assign[d][svc][m.Role]; !ok {
+					continue // role tidak diisi di ibadah ini
+				}
+				if _, done := day[svc][m.Role]; done {
+					continue
+				}
+				cands := FilterCandidates(people, m.SourceColumn, IsMajelisPendamping(m.Role), d)
+				orderNames(rng, cands, d, "cadangan|"+svc+"|"+m.Role, m.SourceColumn)
+				picked := []string{}
+				for _, n := range cands {
+					if len(picked) == cfg.Substitutes {
+						break
+					}
+					if rules.ok(n, used, bySvc[svc]) {
+						used[n] = true
+						picked = append(picked, n)
+					}
+				}
+				day[svc][m.Role] = picked
+				if verbose && len(picked) > 0 {
+					cfg.logf("    cadangan %s (%s.00): %s\n", m.Role, svc, strings.Join(picked, ", "))
+				}
+			}
+		}
+		cfg.Backups[d] = day
+	}
+
+	for di, d := range dates {
+		rng := dateRand(cfg.Seed, d)
+		if assign[d] == nil {
+			assign[d] = map[string]map[string][]string{}
+		}
+		for _, roles := range kept[d.Format("2006-01-02")] {
+			for role, names := range roles {
+				for _, n := range names {
+					markServed(n, d, role)
+				}
+			}
+		}
+		if day, ok := locked[d.Format("2006-01-02")]; ok {
+			for svc, roles := range day {
+				assign[d][svc] = map[string][]string{}
+				for role, names := range roles {
+					assign[d][svc][role] = append([]string(nil), names...)
+					for _, n := range names {
+						markServed(n, d, role)
+					}
+				}
+			}
+			if verbose {
+				cfg.logf("=== %s === (terkunci, isi dipakai apa adanya)\n", d.Format("Mon, 02 Jan 2006"))
+			}
+			continue
+		}
+		assignedSvc := map[string]map[string]bool{} // service -> nama sudah bertugas
+		for _, svc := range services {
+			assignedSvc[svc] = map[string]bool{}
+		}
+		assignedAnyToday := map[string]bool{}
+		for _, byRole := range pins[d.Format("2006-01-02")] {
+			for _, names := range byRole {
+				for _, n := range names {
+					assignedAnyToday[n] = true // pin di ibadah mana pun hari ini
+				}
+			}
+		}
+		usedHousehold := map[string]map[string]bool{} // service -> ID keluarga sudah bertugas
+		for _, svc := range services {
+			usedHousehold[svc] = map[string]bool{}
+		}
+		// Kept: role lain di ibadah itu sudah terisi sebelum pengisian dimulai
+		for svc, roles := range kept[d.Format("2006-01-02")] {
+			if assignedSvc[svc] == nil {
+				continue // ibadah tidak dijadwalkan run ini
+			}
+			for _, names := range roles {
+				for _, n := range names {
+					assignedSvc[svc][n] = true
+					assignedAnyToday[n] = true
+					if h := householdIdx[n]; h != "" {
+						usedHousehold[svc][h] = true
+					}
+				}
+			}
+		}
+
+		ds := d.Format("2006-01-02")
+		if verbose {
+			cfg.logf("=== %s ===\n", d.Format("Mon, 02 Jan 2006"))
+		}
+
+		for _, svc := range services {
+			if assign[d][svc] == nil {
+				assign[d][svc] = map[string][]string{}
+			}
+			if verbose {
+				cfg.logf("  [Service %s]\n", svc)
+			}
+
+			// one-line summary holders untuk komposisi
+			compStatus := map[string]string{"kolektan": "N/A", "pjemaat": "N/A"}
+
+			grouped, others := groupMappingsForService(maps, svc)
+			pinned := pins[ds][svc] // label role -> nama (-pin)
+
+			// ---- Split others menjadi MP vs non-MP
+			mpRows := []RoleMap{}
+			otherNonMP := []RoleMap{}
+			for _, m := range others {
+				if !InService(m, svc) {
+					continue
+				}
+				if IsMajelisPendamping(m.Role) {
+					if len(m.Services) == 0 && svc != lastSvc {
+						continue
+					}
+					mpRows = append(mpRows, m)
+				} else {
+					otherNonMP = append(otherNonMP, m)
+				}
+			}
+
+			// ---- prefer function (hindari yang bertugas dalam N Minggu terakhir);
+			// N per role dari kolom CooldownWeeks, selain itu cooldown global.
+			// Dengan CooldownPerRole hanya tugas di role dasar yang sama (plus prior) dihitung.
+			preferFor := func(rows ...RoleMap) func(string) bool {
+				var window, ahead []time.Time
+				if n := RoleCooldown(rows, cooldown); n > 0 {
+					window = scheduled[max(0, offset+di-n) : offset+di]
+					ahead = scheduled[offset+di+1 : min(len(scheduled), offset+di+1+n)]
+				}
+				hit := func(ts, in []time.Time) bool {
+					for _, t := range ts {
+						for _, w := range in {
+							if SameDay(t, w) {
+								return true
+							}
+						}
+					}
+					return false
+				}
+				if cfg.CooldownPerRole && len(rows) > 0 {
+					key := BaseRole(rows[0].Role)
+					return func(name string) bool {
+						return !hit(priorDates[name], window) && !hit(servedRole[name][key], window) && !hit(lockedOn[name], ahead)
+					}
+				}
+				return func(name string) bool { return !hit(servedDates[name], window) && !hit(lockedOn[name], ahead) }
+			}
+
+			// ---- -noSameHousehold: satu keluarga maksimal satu orang per ibadah
+			sameHousehold := func(name string) bool {
+				h := householdIdx[name]
+				return cfg.NoSameHousehold && h != "" && usedHousehold[svc][h]
+			}
+			takeHousehold := func(name string) {
+				if h := householdIdx[name]; h != "" {
+					usedHousehold[svc][h] = true
+				}
+			}
+			// batasan keras tambahan: keluarga sama & daftar Hindari (simetris)
+			blocked := func(name string) bool {
+				return sameHousehold(name) || avoidConflict(avoidIdx, name, assignedSvc[svc])
+			}
+
+			// ---- CrossService: daftar "sudah bertugas hari ini" yang dicek untuk rows;
+			// kosong bila semua barisnya allow (dan -noCrossService tidak aktif)
+			todayFor := func(rows ...RoleMap) map[string]bool {
+				if cfg.NoCrossService || len(rows) == 0 {
+					return assignedAnyToday
+				}
+				for _, m := range rows {
+					if m.CrossService != CrossAllow {
+						return assignedAnyToday
+					}
+				}
+				return map[string]bool{}
+			}
+
+			// ---- Pasangan: setelah name terpilih, isi slot berikutnya di role yang sama
+			// dengan pasangannya bila eligible & lolos semua batasan (hanya dorongan)
+			// (prefer nil = fase relax, tanpa cek anti-B2B)
+			pairUp := func(name, role string, pool []string, prefer func(string) bool, picked *[]string, limit int, tr *pendingTrace) {
+				pn := partnerIdx[name]
+				if pn == "" || len(*picked) >= limit || containsName(*picked, pn) {
+					return
+				}
+				reason := ""
+				switch {
+				case !containsName(pool, pn):
+					reason = "tidak eligible/tersedia"
+				case assignedSvc[svc][pn] || assignedAnyToday[pn] || blocked(pn):
+					reason = "sudah bertugas/terhalang"
+				case prefer != nil && !prefer(pn):
+					reason = "baru bertugas (anti-B2B)"
+				}
+				if reason != "" {
+					if verbose {
+						cfg.logf("      pasangan %s untuk %s dilewati: %s\n", pn, name, reason)
+					}
+					return
+				}
+				*picked = append(*picked, pn)
+				assignedSvc[svc][pn] = true
+				assignedAnyToday[pn] = true
+				takeHousehold(pn)
+				markServed(pn, d, role)
+				tr.rec(pn, "pasangan", len(pool), nil)
+				if verbose {
+					cfg.logk(LogPick, "      pick(pasangan) %-20s <- %s\n", pn, name)
+				}
+			}
+
+			// ======================================================
+			// 1) Majelis Pendamping (prioritas pertama, default 10.00)
+			// ======================================================
+			fillMP := func(m RoleMap) {
+				already := assignedSvc[svc]
+				slots := cfg.roleSlots(m, svc)
+				cands, capped := withinCap(FilterCandidates(people, m.SourceColumn, true, d)) // wajib Penatua
+				orderNames(rng, cands, d, svc+"/"+m.Role, m.SourceColumn)
+				prefer := preferFor(m)
+				today := todayFor(m)
+
+				picked := append([]string{}, pinned[m.Role]...)
+				tr := newPending(cfg.Trace, ds, svc)
+				sk := newSkipped(cfg.Trace)
+				// (a) hormati prefer (hindari back-to-back), no double-role 10.00, no multi-role/day
+				for _, name := range cands {
+					if len(picked) >= slots {
+						break
+					}
+					if already[name] || today[name] {
+						sk.add(skipAssigned, name)
+						continue
+					}
+					if blocked(name) {
+						sk.add(skipBlocked, name)
+						continue
+					}
+					if !prefer(name) {
+						sk.add(skipB2B, name)
+						continue
+					}
+					picked = append(picked, name)
+					already[name] = true
+					assignedAnyToday[name] = true
+					takeHousehold(name)
+					markServed(name, d, m.Role)
+					tr.rec(name, "prefer", len(cands), sk)
+				}
+				// (b) RELAX khusus MP: boleh ambil dari yang sudah bertugas di ibadah lain hari sama
+				if len(picked) < slots {
+					sk = newSkipped(cfg.Trace)
+					for _, name := range cands {
+						if len(picked) >= slots {
+							break
+						}
+						if already[name] {
+							sk.add(skipAssigned, name)
+							continue // tetap jangan dua peran di ibadah yang sama
+						}
+						noCross := cfg.NoCrossService || m.CrossService == CrossDeny
+						if blocked(name) || (noCross && assignedAnyToday[name]) {
+							sk.add(skipBlocked, name)
+							continue
+						}
+						// izinkan meski assignedAnyToday[name] == true (dari ibadah lain)
+						picked = append(picked, name)
+						already[name] = true
+						assignedAnyToday[name] = true
+						takeHousehold(name)
+						markServed(name, d, m.Role)
+						tr.rec(name, "relax-mp", len(cands), sk)
+						if verbose {
+							cfg.logk(LogRelax, "      pick(MP-relax) %-20s\n", name)
+						}
+					}
+				}
+				plan.set(d, svc, m.Role, slots)
+				capWarn(d, svc, m.Role, capped, slots-len(picked))
+				assign[d][svc][m.Role] = picked
+				tr.flush(len(picked), func(int) string { return m.Role })
+			}
+
+			// ======================================================
+			// 2) Komposisi: Kolektan & P. Jemaat (kedua)
+			// ======================================================
+			fillComposition := func(key string) {
+				rows := grouped[key]
+				if len(rows) == 0 {
+					return
+				}
+				// kuota bulanan, atau kuota khusus tanggal ini (mis. -kolektanPatternOn)
+				q := cfg.quota(key, d)
+				needPen, needJem := q.Penatua, q.Jemaat
+				if _, ok := cfg.QuotaOn[key][ds]; ok && verbose {
+					cfg.logf("    %s kuota override (P:%d J:%d)\n", key, needPen, needJem)
+				}
+
+				totalNeed := groupNeed(cfg.groupLimit(key, svc, d), rows)
+				// pin mengisi sebagian baris; kuota P/J sisanya dikurangi sesuai status pin
+				fixed, free := pinRows(rows, totalNeed, pinned)
+				for i := range rows {
+					n, ok := fixed[i]
+					switch {
+					case !ok:
+					case penIdx[n] && needPen > 0:
+						needPen--
+					case needJem > 0:
+						needJem--
+					case needPen > 0:
+						needPen--
+					}
+				}
+				open := len(free)
+
+				penNames, jemNames := []string{}, []string{}
+				for _, rm := range rows {
+					p, j := filterCandidatesSplit(people, rm.SourceColumn, d)
+					penNames = append(penNames, p...)
+					jemNames = append(jemNames, j...)
+				}
+				penNames = uniq(penNames)
+				jemNames = uniq(jemNames)
+
+				// Pool secara struktural tidak cukup -> selalu beri peringatan
+				// (sekali per kombinasi, tidak tergantung -v)
+				if len(penNames) < needPen || len(jemNames) < needJem {
+					wk := fmt.Sprintf("%s/%s/%d/%d", key, svc, len(penNames), len(jemNames))
+					if !poolWarned[wk] {
+						poolWarned[wk] = true
+						cfg.logk(LogWarn, "WARN: komposisi %s (%s.00) minta P:%d J:%d, tersedia P:%d J:%d (mulai %s); sesuaikan pola\n",
+							strings.Title(key), svc, needPen, needJem, len(penNames), len(jemNames), d.Format("02-01-2006"))
+					}
+				}
+				var cappedP, cappedJ int
+				penNames, cappedP = withinCap(penNames)
+				jemNames, cappedJ = withinCap(jemNames)
+				if verbose {
+					cfg.logf("    %s pool => penatua:%d, jemaat:%d (need P:%d J:%d)\n",
+						key, len(penNames), len(jemaatNames(jemNames)), needPen, needJem)
+				}
+
+				var candPen, candJem []Person
+				for _, n := range penNames {
+					candPen = append(candPen, Person{Name: n, IsPenatua: true, Unavailable: unavailIdx[n]})
+				}
+				for _, n := range jemNames {
+					candJem = append(candJem, Person{Name: n, IsPenatua: false, Unavailable: unavailIdx[n]})
+				}
+				src := rows[0].SourceColumn
+				already := assignedSvc[svc]
+				prefer := preferFor(rows...)
+				// urutan kandidat percobaan ke-a; percobaan 1 = urutan biasa
+				poolsFor := func(a int) []compPool {
+					pen := append([]Person(nil), candPen...)
+					jem := append([]Person(nil), candJem...)
+					tag := ""
+					if a > 1 {
+						tag = fmt.Sprintf("#%d", a)
+					}
+					orderPeople(rng, pen, d, svc+"/"+key+"/P"+tag, src)
+					orderPeople(rng, jem, d, svc+"/"+key+"/J"+tag, src)
+					return []compPool{{Name: "P", Cands: pen, Need: needPen}, {Name: "J", Cands: jem, Need: needJem}}
+				}
+				pools := poolsFor(1)
+				// keadaan yang diubah satu pick komposisi; restore mengembalikannya
+				saveState := func() (restore func()) {
+					a, t, h := copySet(already), copySet(assignedAnyToday), copySet(usedHousehold[svc])
+					return func() {
+						restoreSet(already, a)
+						restoreSet(assignedAnyToday, t)
+						restoreSet(usedHousehold[svc], h)
+					}
+				}
+				// -maxAttempts (hanya strict): coba urutan lain tanpa menyimpan hasil,
+				// lalu pakai percobaan dengan slot kosong paling sedikit
+				if strict && cfg.MaxAttempts > 1 {
+					tries := map[int][]compPool{1: pools}
+					best, used := bestAttempt(cfg.MaxAttempts, func(a int) int {
+						if tries[a] == nil {
+							tries[a] = poolsFor(a)
+						}
+						restore := saveState()
+						dry := pickWithComposition(tries[a], d, rng, func(*rand.Rand, []Person) {}, prefer, already, todayFor(rows...), blocked,
+							func(name, _ string, _ int, _ *skipped) {
+								assignedAnyToday[name] = true
+								takeHousehold(name)
+							}, strict, noRelaxB2B, nil)
+						restore()
+						return open - min(len(dry), open)
+					})
+					pools = tries[best]
+					if verbose {
+						cfg.logf("    %s percobaan komposisi: %d dari maks %d, dipakai ke-%d\n", key, used, cfg.MaxAttempts, best)
+					}
+				}
+				tr := newPending(cfg.Trace, ds, svc)
+				onPick := func(name, stage string, pool int, sk *skipped) {
+					assignedAnyToday[name] = true
+					takeHousehold(name)
+					tr.rec(name, stage, pool, sk)
+				}
+				var restoreBefore func()
+				if cfg.Optimal {
+					restoreBefore = saveState()
+				}
+				picked := pickWithComposition(pools, d, rng,
+					func(rng *rand.Rand, ps []Person) { orderPeople(rng, ps, d, svc+"/"+key+"/any", src) }, prefer, already, todayFor(rows...), blocked, onPick, strict, noRelaxB2B, pickLog)
+				// -optimal: greedy kurang penuh -> coba matching dari keadaan sebelum greedy,
+				// dipakai hanya bila mengisi lebih banyak slot
+				if cfg.Optimal && len(picked) < needPen+needJem {
+					restoreGreedy := saveState()
+					restoreBefore()
+					household := func(string) string { return "" }
+					if cfg.NoSameHousehold {
+						household = func(name string) string { return householdIdx[name] }
+					}
+					today := todayFor(rows...)
+					opt := matchComposition(pools, d, prefer, already, today, blocked, household, strict, noRelaxB2B)
+					if len(opt) > len(picked) {
+						greedy := len(picked)
+						tr = newPending(cfg.Trace, ds, svc)
+						picked = picked[:0]
+						for _, name := range opt {
+							if blocked(name) { // mis. Hindari terhadap pick sebelumnya
+								continue
+							}
+							picked = append(picked, name)
+							already[name] = true
+							today[name] = true
+							onPick(name, "optimal", len(candPen)+len(candJem), &skipped{})
+						}
+						if verbose {
+							cfg.logf("    %s -optimal: greedy %d slot, matching %d slot\n", key, greedy, len(picked))
+						}
+					} else {
+						restoreGreedy()
+					}
+				}
+				if len(picked) > open {
+					picked = picked[:open]
+				}
+				planRows(plan, d, svc, rows, totalNeed)
+				capWarn(d, svc, strings.Title(key), cappedP+cappedJ, open-len(picked))
+				spreadPicks(assign[d][svc], rows, mergeRows(rows, fixed, free, picked))
+				for i := range picked {
+					markServed(picked[i], d, rows[free[i]].Role)
+				}
+				tr.flush(len(picked), func(i int) string { return rows[free[i]].Role })
+
+				// --- Summary per service untuk komposisi (display only)
+				if verbose {
+					// count actual P/J dari picked
+					countP := 0
+					for _, n := range picked {
+						if penIdx[n] {
+							countP++
+						}
+					}
+					countJ := len(picked) - countP
+
+					reqTotal := totalNeed
+					rem := reqTotal
+					reqP := needPen
+					if reqP > rem {
+						reqP = rem
+					}
+					rem -= reqP
+					reqJ := needJem
+					if reqJ > rem {
+						reqJ = rem
+					}
+
+					missingP := 0
+					if countP < reqP {
+						missingP = reqP - countP
+					}
+					missingJ := 0
+					if countJ < reqJ {
+						missingJ = reqJ - countJ
+					}
+					missingSlots := reqTotal - len(picked)
+
+					status := "OK"
+					if missingP > 0 || missingJ > 0 || (strict && missingSlots > 0) {
+						status = fmt.Sprintf("KURANG (P:%d J:%d slot:%d)", missingP, missingJ, missingSlots)
+					}
+					cfg.logf("    Rekap komposisi %s (%s): %s\n", strings.Title(key), svc, status)
+					compStatus[key] = status
+					if strict && missingSlots > 0 {
+						cfg.logk(LogEmpty, "      (kosong: kuota tidak terpenuhi dengan prefer anti-B2B)\n")
+					}
+				}
+			}
+
+			// ======================================================
+			// 3) Lektor / Prokantor / Pemusik (ketiga)
+			// ======================================================
+			fillGroup := func(key string) {
+				rows := grouped[key]
+				if len(rows) == 0 {
+					return
+				}
+				limit := groupNeed(cfg.groupLimit(key, svc, d), rows)
+				fixed, free := pinRows(rows, limit, pinned)
+				open := len(free) // slot yang diisi picker (selain pin)
+				if verbose {
+					cfg.logf("    - Group %-10s | Rows: %d | Limit: %d\n", key, len(rows), limit)
+				}
+				src := rows[0].SourceColumn
+				names, capped := withinCap(FilterCandidates(people, src, false, d)) // tidak wajib Penatua
+				orderNames(rng, names, d, svc+"/"+key, src)
+				prefer := preferFor(rows...)
+				today := todayFor(rows...)
+
+				already := assignedSvc[svc]
+
+				picked := []string{}
+				tr := newPending(cfg.Trace, ds, svc)
+				sk := newSkipped(cfg.Trace)
+				for _, name := range names {
+					if len(picked) >= open {
+						break
+					}
+					if already[name] || today[name] {
+						sk.add(skipAssigned, name)
+						continue
+					}
+					if blocked(name) {
+						sk.add(skipBlocked, name)
+						continue
+					}
+					if !prefer(name) {
+						sk.add(skipB2B, name)
+						continue
+					}
+					picked = append(picked, name)
+					already[name] = true
+					assignedAnyToday[name] = true
+					takeHousehold(name)
+					markServed(name, d, key)
+					tr.rec(name, "prefer", len(names), sk)
+					if verbose {
+						cfg.logk(LogPick, "      pick %-20s\n", name)
+					}
+					pairUp(name, key, names, prefer, &picked, open, tr)
+				}
+
+				// RELAX phase (fill remaining) -> ONLY if noRelaxB2B is OFF
+				if !noRelaxB2B && len(picked) < open {
+					sk = newSkipped(cfg.Trace)
+					for _, name := range names {
+						if len(picked) >= open {
+							break
+						}
+						if already[name] || today[name] {
+							sk.add(skipAssigned, name)
+							continue
+						}
+						if blocked(name) {
+							sk.add(skipBlocked, name)
+							continue
+						}
+						picked = append(picked, name)
+						already[name] = true
+						assignedAnyToday[name] = true
+						takeHousehold(name)
+						markServed(name, d, key)
+						tr.rec(name, "relax", len(names), sk)
+						if verbose {
+							cfg.logk(LogRelax, "      pick(relax) %-12s\n", name)
+						}
+						pairUp(name, key, names, nil, &picked, open, tr)
+					}
+				}
+
+				planRows(plan, d, svc, rows, limit)
+				capWarn(d, svc, strings.Title(key), capped, open-len(picked))
+				spreadPicks(assign[d][svc], rows, mergeRows(rows, fixed, free, picked))
+				tr.flush(len(picked), func(i int) string { return rows[free[i]].Role })
+			}
+
+			// ---- SamePerson: salin hasil role ini dari ibadah sebelumnya di tanggal
+			// yang sama (bila ada) alih-alih memilih ulang. Salinan tetap dicek
+			// seperti pengisian biasa (sudah bertugas di ibadah ini, Hindari/keluarga,
+			// -maxPerPerson); gagal -> false, role dipilih biasa. Salinan tidak
+			// menambah hitungan tugas (tugas yang sama, hari yang sama).
+			copySamePerson := func(m RoleMap, slots int) bool {
+				for _, prevSvc := range services {
+					if prevSvc == svc {
+						return false
+					}
+					prev, ok := assign[d][prevSvc][m.Role]
+					if !ok {
+						continue
+					}
+					for _, name := range prev {
+						if assignedSvc[svc][name] || blocked(name) || (maxPer > 0 && assignCount[name] > maxPer) {
+							if verbose {
+								cfg.logf("      same-person %s dari %s.00 dilewati: sudah bertugas/terhalang\n", name, prevSvc)
+							}
+							return false
+						}
+					}
+					picked := append([]string{}, prev...)
+					tr := newPending(cfg.Trace, ds, svc)
+					for _, name := range picked {
+						assignedSvc[svc][name] = true
+						takeHousehold(name)
+						tr.rec(name, "same-person", len(picked), nil)
+						if verbose {
+							cfg.logk(LogPick, "      pick(same-person) %-20s <- %s.00\n", name, prevSvc)
+						}
+					}
+					plan.set(d, svc, m.Role, slots)
+					assign[d][svc][m.Role] = picked
+					tr.flush(len(picked), func(int) string { return m.Role })
+					return true
+				}
+				return false
+			}
+
+			// ======================================================
+			// 4) Role lainnya (non-MP)
+			// ======================================================
+			fillOther := func(m RoleMap) {
+				if !InService(m, svc) {
+					return
+				}
+
+				slots := cfg.roleSlots(m, svc)
+				if m.SamePerson && len(pinned[m.Role]) == 0 && copySamePerson(m, slots) {
+					return
+				}
+
+				cands, capped := withinCap(FilterCandidates(people, m.SourceColumn, IsMajelisPendamping(m.Role), d))
+				orderNames(rng, cands, d, svc+"/"+m.Role, m.SourceColumn)
+				prefer := preferFor(m)
+				today := todayFor(m)
+
+				already := assignedSvc[svc]
+
+				picked := append([]string{}, pinned[m.Role]...)
+				tr := newPending(cfg.Trace, ds, svc)
+				sk := newSkipped(cfg.Trace)
+				for _, name := range cands {
+					if len(picked) >= slots {
+						break
+					}
+					if already[name] || today[name] {
+						sk.add(skipAssigned, name)
+						continue
+					}
+					if blocked(name) {
+						sk.add(skipBlocked, name)
+						continue
+					}
+					if !prefer(name) {
+						sk.add(skipB2B, name)
+						continue
+					}
+					picked = append(picked, name)
+					already[name] = true
+					assignedAnyToday[name] = true
+					takeHousehold(name)
+					markServed(name, d, m.Role)
+					tr.rec(name, "prefer", len(cands), sk)
+					pairUp(name, m.Role, cands, prefer, &picked, slots, tr)
+				}
+				// RELAX phase -> ONLY if noRelaxB2B is OFF
+				if !noRelaxB2B && len(picked) < slots {
+					sk = newSkipped(cfg.Trace)
+					for _, name := range cands {
+						if len(picked) >= slots {
+							break
+						}
+						if already[name] || today[name] {
+							sk.add(skipAssigned, name)
+							continue
+						}
+						if blocked(name) {
+							sk.add(skipBlocked, name)
+							continue
+						}
+						picked = append(picked, name)
+						already[name] = true
+						assignedAnyToday[name] = true
+						takeHousehold(name)
+						markServed(name, d, m.Role)
+						tr.rec(name, "relax", len(cands), sk)
+						pairUp(name, m.Role, cands, nil, &picked, slots, tr)
+					}
+				}
+				plan.set(d, svc, m.Role, slots)
+				capWarn(d, svc, m.Role, capped, slots-len(picked))
+				assign[d][svc][m.Role] = picked
+				tr.flush(len(picked), func(int) string { return m.Role })
+			}
+
+			// ---- -pin: penugasan tetap ditempatkan lebih dulu; role-nya diisi di sekitarnya
+			for _, role := range sortedPinRoles(pinned) {
+				for _, name := range pinned[role] {
+					assignedSvc[svc][name] = true
+					assignedAnyToday[name] = true
+					takeHousehold(name)
+					markServed(name, d, role)
+					tr := newPending(cfg.Trace, ds, svc)
+					tr.rec(name, "pin", 1, nil)
+					tr.flush(1, func(int) string { return role })
+					if verbose {
+						cfg.logf("      pin %-20s -> %s\n", name, role)
+					}
+				}
+			}
+
+			// ---- Urutan pengisian: role ber-Priority (MappingRole) naik lebih dulu,
+			// sisanya urutan bawaan MP -> komposisi -> lektor/prokantor/pemusik -> lainnya
+			var units []fillUnit
+			for _, m := range mpRows {
+				m := m
+				units = append(units, fillUnit{m.Priority, func() { fillMP(m) }})
+			}
+			for _, key := range []string{"kolektan", "pjemaat"} {
+				key := key
+				units = append(units, fillUnit{groupPriority(grouped[key]), func() { fillComposition(key) }})
+			}
+			for _, key := range []string{"lektor", "prokantor", "pemusik"} {
+				key := key
+				units = append(units, fillUnit{groupPriority(grouped[key]), func() { fillGroup(key) }})
+			}
+			for _, m := range otherNonMP {
+				m := m
+				units = append(units, fillUnit{m.Priority, func() { fillOther(m) }})
+			}
+			sortFillUnits(units)
+			for _, u := range units {
+				u.run()
+			}
+
+			// One-line summary per service (Kolektan & P. Jemaat)
+			if verbose {
+				cfg.logf("    Summary %s.00: Kolektan %s | P.Jemaat %s\n", svc, compStatus["kolektan"], compStatus["pjemaat"])
+			}
+		}
+		if cfg.Substitutes > 0 && cfg.Backups != nil {
+			pickBackups(rng, d)
+		}
+	}
+	return assign, nil
+}
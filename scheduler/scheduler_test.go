@@ -0,0 +1,381 @@
+package scheduler
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestBuildAvoidIndexSymmetric(t *testing.T) {
+	people := []Person{
+		{Name: "A", Avoid: []string{"B"}},
+		{Name: "B"},
+		{Name: "C", Avoid: []string{"C"}},
+	}
+	idx := BuildAvoidIndex(people)
+	if !idx["A"]["B"] || !idx["B"]["A"] {
+		t.Fatalf("relasi A<->B harus simetris, dapat %v", idx)
+	}
+	if len(idx["C"]) != 0 {
+		t.Fatalf("C tidak boleh menghindari diri sendiri, dapat %v", idx["C"])
+	}
+
+	if !avoidConflict(idx, "B", map[string]bool{"A": true}) {
+		t.Error("B harus konflik dengan A (dideklarasikan hanya di sisi A)")
+	}
+	if !avoidConflict(idx, "A", map[string]bool{"B": true}) {
+		t.Error("A harus konflik dengan B")
+	}
+	if avoidConflict(idx, "C", map[string]bool{"A": true, "B": true}) {
+		t.Error("C tidak punya konflik")
+	}
+}
+
+func TestGenerateScheduleAvoidSameService(t *testing.T) {
+	lektor := map[string]bool{"lektor": true}
+	maps := []RoleMap{
+		{Role: "Lektor 1", SourceColumn: "Lektor", Services: []string{"07"}},
+		{Role: "Lektor 2", SourceColumn: "Lektor", Services: []string{"07"}},
+	}
+	dates := []time.Time{
+		time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 17, 0, 0, 0, 0, time.UTC),
+	}
+
+	for _, declaredOn := range []string{"A", "B"} {
+		people := []Person{
+			{Name: "A", Marks: lektor},
+			{Name: "B", Marks: lektor},
+			{Name: "C", Marks: lektor},
+		}
+		for i := range people {
+			if people[i].Name == declaredOn {
+				people[i].Avoid = []string{map[string]string{"A": "B", "B": "A"}[declaredOn]}
+			}
+		}
+		for seed := int64(1); seed <= 20; seed++ {
+			cfg := NewConfig(dates)
+			cfg.Seed = seed
+			cfg.CooldownWeeks = 0
+			assign, err := GenerateSchedule(cfg, people, maps)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, d := range dates {
+				got := map[string]bool{}
+				for _, role := range []string{"Lektor 1", "Lektor 2"} {
+					for _, n := range assign[d]["07"][role] {
+						got[n] = true
+					}
+				}
+				if got["A"] && got["B"] {
+					t.Fatalf("Hindari di sisi %s, seed %d, %s: A dan B satu ibadah", declaredOn, seed, d.Format("2006-01-02"))
+				}
+			}
+		}
+	}
+}
+
+func TestGenerateScheduleSamePerson(t *testing.T) {
+	sound := map[string]bool{"sound": true}
+	maps := []RoleMap{{Role: "Sound", SourceColumn: "Sound", SamePerson: true}}
+	people := []Person{{Name: "A", Marks: sound}, {Name: "B", Marks: sound}, {Name: "C", Marks: sound}}
+	dates := []time.Time{
+		time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		cfg := NewConfig(dates)
+		cfg.Seed = seed
+		cfg.NoCrossService = true // duplikat lintas ibadah di sini disengaja
+		assign, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, d := range dates {
+			a, b := assign[d]["07"]["Sound"], assign[d]["10"]["Sound"]
+			if len(a) != 1 || !reflect.DeepEqual(a, b) {
+				t.Fatalf("seed %d, %s: 07=%v 10=%v, ingin orang yang sama", seed, d.Format("2006-01-02"), a, b)
+			}
+		}
+	}
+}
+
+// SamePerson: orang yang sudah bertugas di ibadah tujuan tidak disalin
+// (role dipilih biasa), dan salinan tidak dihitung dua kali untuk -maxPerPerson.
+func TestGenerateScheduleSamePersonChecks(t *testing.T) {
+	marks := map[string]bool{"sound": true, "lektor": true}
+	maps := []RoleMap{
+		{Role: "Lektor 1", SourceColumn: "Lektor", Services: []string{"10"}},
+		{Role: "Sound", SourceColumn: "Sound", SamePerson: true},
+	}
+	people := []Person{{Name: "A", Marks: marks}, {Name: "B", Marks: map[string]bool{"sound": true}}}
+	dates := []time.Time{time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)}
+
+	for seed := int64(1); seed <= 10; seed++ {
+		cfg := NewConfig(dates)
+		cfg.Seed = seed
+		cfg.Pins = []Pin{
+			{Date: "2025-08-03", Service: "07", Role: "Sound", Name: "A"},
+			{Date: "2025-08-03", Service: "10", Role: "Lektor 1", Name: "A"},
+		}
+		assign, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := assign[dates[0]]["10"]["Sound"]; !reflect.DeepEqual(got, []string{"B"}) {
+			t.Fatalf("seed %d: Sound 10 = %v, ingin [B] (A sudah Lektor 1 di 10)", seed, got)
+		}
+	}
+
+	// satu orang, -maxPerPerson 2: salinan minggu pertama tidak memakan jatah minggu kedua
+	sound := []Person{{Name: "Z", Marks: marks}}
+	two := []time.Time{dates[0], time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC)}
+	cfg := NewConfig(two)
+	cfg.CooldownWeeks = 0
+	cfg.MaxPerPerson = 2
+	assign, err := GenerateSchedule(cfg, sound, maps[1:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range two {
+		if a, b := assign[d]["07"]["Sound"], assign[d]["10"]["Sound"]; len(a) != 1 || !reflect.DeepEqual(a, b) {
+			t.Fatalf("%s: 07=%v 10=%v, ingin Z di keduanya", d.Format("2006-01-02"), a, b)
+		}
+	}
+}
+
+func TestGenerateSchedulePins(t *testing.T) {
+	lektor := map[string]bool{"lektor": true}
+	people := []Person{{Name: "A", Marks: lektor}, {Name: "B", Marks: lektor}, {Name: "C", Marks: lektor}, {Name: "D"}}
+	maps := []RoleMap{
+		{Role: "Lektor 1", SourceColumn: "Lektor", Services: []string{"07"}},
+		{Role: "Lektor 2", SourceColumn: "Lektor", Services: []string{"07"}},
+	}
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+
+	for seed := int64(1); seed <= 20; seed++ {
+		cfg := NewConfig([]time.Time{d})
+		cfg.Seed = seed
+		cfg.Pins = []Pin{
+			{Date: "2025-08-03", Service: "07", Role: "Lektor 2", Name: "c"}, // nama tanpa beda huruf
+			{Date: "2025-08-03", Service: "07", Role: "Lektor", Name: "D"},   // tidak eligible: dilewati
+		}
+		assign, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		l1, l2 := assign[d]["07"]["Lektor 1"], assign[d]["07"]["Lektor 2"]
+		if !reflect.DeepEqual(l2, []string{"C"}) {
+			t.Fatalf("seed %d: Lektor 2 = %v, ingin pin [C]", seed, l2)
+		}
+		if len(l1) != 1 || l1[0] == "C" || l1[0] == "D" {
+			t.Fatalf("seed %d: Lektor 1 = %v, ingin A atau B", seed, l1)
+		}
+	}
+}
+
+func TestGenerateScheduleLocked(t *testing.T) {
+	lektor := map[string]bool{"lektor": true}
+	people := []Person{{Name: "A", Marks: lektor}, {Name: "B", Marks: lektor}, {Name: "C", Marks: lektor}}
+	maps := []RoleMap{{Role: "Lektor 1", SourceColumn: "Lektor", Services: []string{"07"}}}
+	dates := []time.Time{
+		time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 17, 0, 0, 0, 0, time.UTC),
+	}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		cfg := NewConfig(dates)
+		cfg.Seed = seed
+		cfg.Locked = Assignment{dates[1]: {"07": {"Lektor 1": {"A"}}}}
+		assign, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := assign[dates[1]]["07"]["Lektor 1"]; !reflect.DeepEqual(got, []string{"A"}) {
+			t.Fatalf("seed %d: tanggal terkunci = %v, ingin [A]", seed, got)
+		}
+		// A bertugas di tanggal terkunci: tidak dipilih di minggu sebelum maupun sesudahnya
+		for _, d := range []time.Time{dates[0], dates[2]} {
+			if got := assign[d]["07"]["Lektor 1"]; len(got) != 1 || got[0] == "A" {
+				t.Fatalf("seed %d, %s: Lektor 1 = %v, ingin B atau C", seed, d.Format("2006-01-02"), got)
+			}
+		}
+	}
+}
+
+// Cadangan tidak boleh Hindari atau (dengan NoSameHousehold) satu keluarga
+// dengan petugas ibadahnya, termasuk orang -pin.
+func TestGenerateScheduleSubstitutesRules(t *testing.T) {
+	lektor := map[string]bool{"lektor": true}
+	people := []Person{
+		{Name: "A", Marks: lektor, Household: "K1"},
+		{Name: "B", Marks: lektor, Avoid: []string{"A"}},
+		{Name: "C", Marks: lektor, Household: "K1"},
+		{Name: "D", Marks: lektor},
+		{Name: "E", Marks: lektor},
+	}
+	maps := []RoleMap{{Role: "Lektor 1", SourceColumn: "Lektor", Services: []string{"07"}}}
+	dates := []time.Time{time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)}
+
+	for seed := int64(1); seed <= 10; seed++ {
+		cfg := NewConfig(dates)
+		cfg.Seed = seed
+		cfg.NoSameHousehold = true
+		cfg.Pins = []Pin{{Date: "2025-08-03", Service: "07", Role: "Lektor 1", Name: "A"}}
+		cfg.Substitutes = 4
+		cfg.Backups = Assignment{}
+		if _, err := GenerateSchedule(cfg, people, maps); err != nil {
+			t.Fatal(err)
+		}
+		got := append([]string(nil), cfg.Backups[dates[0]]["07"]["Lektor 1"]...)
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, []string{"D", "E"}) {
+			t.Fatalf("seed %d: cadangan = %v, ingin [D E]", seed, got)
+		}
+	}
+}
+
+// Kept (-roles dengan -merge): petugas role lain di ibadah itu tidak
+// dipilih lagi, dan tanggalnya ikut cooldown ke minggu sebelumnya.
+func TestGenerateScheduleKept(t *testing.T) {
+	lektor := map[string]bool{"lektor": true}
+	var people []Person
+	for _, n := range []string{"A", "B", "C", "D"} {
+		people = append(people, Person{Name: n, Marks: lektor})
+	}
+	maps := []RoleMap{
+		{Role: "Lektor 1", SourceColumn: "Lektor", Services: []string{"07"}},
+		{Role: "Lektor 2", SourceColumn: "Lektor", Services: []string{"07"}},
+	}
+	dates := []time.Time{
+		time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		cfg := NewConfig(dates)
+		cfg.Seed = seed
+		cfg.Kept = Assignment{
+			dates[0]: {"07": {"Kolektan 1": {"B"}}},
+			dates[1]: {"07": {"Kolektan 1": {"A"}}},
+		}
+		assign, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := func(d time.Time) []string {
+			return append(append([]string(nil), assign[d]["07"]["Lektor 1"]...), assign[d]["07"]["Lektor 2"]...)
+		}
+		if g := got(dates[0]); containsName(g, "B") || containsName(g, "A") {
+			t.Fatalf("seed %d, %s: Lektor = %v, ingin C & D", seed, dates[0].Format("2006-01-02"), g)
+		}
+		if g := got(dates[1]); containsName(g, "A") {
+			t.Fatalf("seed %d, %s: Lektor = %v, A sudah Kolektan", seed, dates[1].Format("2006-01-02"), g)
+		}
+		if _, ok := assign[dates[1]]["07"]["Kolektan 1"]; ok {
+			t.Fatalf("seed %d: role Kept ikut hasil", seed)
+		}
+	}
+}
+
+// RNG per tanggal: membuat ulang tanggal terakhir dengan tanggal sebelumnya
+// terkunci (isi dari run penuh) memberi hasil yang sama dengan run penuh.
+func TestGenerateSchedulePerDateRand(t *testing.T) {
+	lektor := map[string]bool{"lektor": true}
+	var people []Person
+	for _, n := range []string{"A", "B", "C", "D", "E", "F"} {
+		people = append(people, Person{Name: n, Marks: lektor})
+	}
+	maps := []RoleMap{
+		{Role: "Lektor 1", SourceColumn: "Lektor", Services: []string{"07"}},
+		{Role: "Lektor 2", SourceColumn: "Lektor", Services: []string{"07"}},
+	}
+	dates := []time.Time{
+		time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	for seed := int64(1); seed <= 20; seed++ {
+		cfg := NewConfig(dates)
+		cfg.Seed = seed
+		cfg.MaxLektor = 2
+		full, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg = NewConfig(dates)
+		cfg.Seed = seed
+		cfg.MaxLektor = 2
+		cfg.Locked = Assignment{dates[0]: full[dates[0]]}
+		part, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(part[dates[1]], full[dates[1]]) {
+			t.Fatalf("seed %d: dibuat ulang %v, run penuh %v", seed, part[dates[1]], full[dates[1]])
+		}
+	}
+}
+
+// Cadangan: eligible, bukan petugas maupun cadangan lain hari itu, paling
+// banyak Substitutes per role, dan tidak mengubah penugasan utama.
+func TestGenerateScheduleSubstitutes(t *testing.T) {
+	lektor := map[string]bool{"lektor": true}
+	var people []Person
+	for _, n := range []string{"A", "B", "C", "D", "E", "F", "G"} {
+		people = append(people, Person{Name: n, Marks: lektor})
+	}
+	people = append(people, Person{Name: "X"}) // tidak eligible
+	maps := []RoleMap{
+		{Role: "Lektor 1", SourceColumn: "Lektor", Services: []string{"07"}},
+		{Role: "Lektor 2", SourceColumn: "Lektor", Services: []string{"07"}},
+	}
+	dates := []time.Time{
+		time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC),
+		time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC),
+	}
+
+	for seed := int64(1); seed <= 10; seed++ {
+		cfg := NewConfig(dates)
+		cfg.Seed = seed
+		cfg.MaxPerPerson = 1
+		plain, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg.Substitutes = 2
+		cfg.Backups = Assignment{}
+		assign, err := GenerateSchedule(cfg, people, maps)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(assign, plain) {
+			t.Fatalf("seed %d: cadangan mengubah penugasan: %v vs %v", seed, assign, plain)
+		}
+		for _, d := range dates {
+			seen := map[string]bool{}
+			for _, names := range assign[d]["07"] {
+				for _, n := range names {
+					seen[n] = true
+				}
+			}
+			for _, m := range maps {
+				backups := cfg.Backups[d]["07"][m.Role]
+				if len(backups) > 2 {
+					t.Errorf("seed %d %s %s: %d cadangan, maks 2", seed, d.Format("02"), m.Role, len(backups))
+				}
+				for _, n := range backups {
+					if n == "X" || seen[n] {
+						t.Errorf("seed %d %s %s: cadangan %s tidak valid", seed, d.Format("02"), m.Role, n)
+					}
+					seen[n] = true
+				}
+			}
+		}
+	}
+}
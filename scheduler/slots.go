@@ -0,0 +1,101 @@
+package scheduler
+
+import "time"
+
+// ==================== Jumlah slot per role ====================
+// Satu sumber hitungan slot yang diminta: dipakai semua jalur pengisian
+// GenerateSchedule (MP, komposisi, grup, lainnya) dan tercatat di SlotPlan
+// untuk laporan kekurangan & ekspor.
+
+// quota: kuota komposisi key ("kolektan"/"pjemaat") pada tanggal d;
+// QuotaOn (mis. -kolektanPatternOn) menimpa kuota bulanan.
+func (c *Config) quota(key string, d time.Time) Quota {
+	if q, ok := c.QuotaOn[key][d.Format("2006-01-02")]; ok {
+		return q
+	}
+	if key == "kolektan" {
+		return c.Kolektan
+	}
+	return c.PJemaat
+}
+
+// groupMax: batas grup Lektor/Prokantor/Pemusik di ibadah svc; MaxOn
+// (mis. -maxLektorService 07=1) menimpa batas umum.
+func (c *Config) groupMax(key, svc string) int {
+	if n, ok := c.MaxOn[key][svc]; ok {
+		return n
+	}
+	switch key {
+	case "lektor":
+		return c.MaxLektor
+	case "prokantor":
+		return c.MaxProkantor
+	case "pemusik":
+		return c.MaxPemusik
+	}
+	return 0
+}
+
+// groupLimit: jumlah orang yang diminta grup key di ibadah svc pada tanggal d,
+// sebelum dibatasi jumlah baris MappingRole.
+func (c *Config) groupLimit(key, svc string, d time.Time) int {
+	switch key {
+	case "kolektan", "pjemaat":
+		q := c.quota(key, d)
+		return q.Penatua + q.Jemaat
+	}
+	return c.groupMax(key, svc)
+}
+
+// groupNeed: slot grup di satu ibadah = batasnya, maksimal jumlah baris grup.
+func groupNeed(limit int, rows []RoleMap) int {
+	if limit > len(rows) {
+		return len(rows)
+	}
+	return limit
+}
+
+// rowSlots: baris grup ke-1..need masing-masing satu slot; label sama dijumlah,
+// baris di luar need tetap tercatat dengan 0.
+func rowSlots(rows []RoleMap, need int) map[string]int {
+	n := map[string]int{}
+	for _, rm := range rows {
+		n[rm.Role] = 0
+	}
+	for i := 0; i < need && i < len(rows); i++ {
+		n[rows[i].Role]++
+	}
+	return n
+}
+
+// roleSlots: slot role non-grup (MP & lainnya) di ibadah svc: kolom SlotsXX
+// bila diisi, selain itu bawaan per role.
+func (c *Config) roleSlots(m RoleMap, svc string) int {
+	if m.Slots[svc] > 0 {
+		return m.Slots[svc]
+	}
+	return defaultSlotsForRole(m.Role, svc, c.groupMax("lektor", svc), c.groupMax("prokantor", svc), c.groupMax("pemusik", svc))
+}
+
+// SlotsFor: jumlah slot yang diminta role (label MappingRole) di ibadah svc
+// pada tanggal d, persis seperti yang dicoba diisi GenerateSchedule.
+// 0 bila role tidak berlaku di svc (termasuk MP tanpa Service di luar ibadah terakhir).
+func (c *Config) SlotsFor(role, svc string, d time.Time, maps []RoleMap) int {
+	grouped, others := groupMappingsForService(maps, svc)
+	if IsGroupRole(role) {
+		key := BaseRole(role)
+		rows := grouped[key]
+		return rowSlots(rows, groupNeed(c.groupLimit(key, svc, d), rows))[role]
+	}
+	services := ServiceKeys(maps, c.Services)
+	for _, m := range others {
+		if m.Role != role {
+			continue
+		}
+		if IsMajelisPendamping(m.Role) && len(m.Services) == 0 && svc != services[len(services)-1] {
+			return 0
+		}
+		return c.roleSlots(m, svc)
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func slotTestMaps() []RoleMap {
+	both := []string{"07", "10"}
+	return []RoleMap{
+		{Role: "Majelis Pendamping", SourceColumn: "MP"},                                                              // tanpa Service: ibadah terakhir saja
+		{Role: "Majelis Pendamping 07", SourceColumn: "MP", Services: []string{"07"}, Slots: map[string]int{"07": 2}}, // SlotsXX
+		{Role: "Multimedia", SourceColumn: "Multimedia", Services: both, Slots: map[string]int{"10": 3}},
+		{Role: "Lektor 1", SourceColumn: "Lektor", Services: both},
+		{Role: "Lektor 2", SourceColumn: "Lektor", Services: both},
+		{Role: "Lektor 3", SourceColumn: "Lektor", Services: []string{"10"}},
+		{Role: "Pemusik", SourceColumn: "Pemusik", Services: both},
+		{Role: "Pemusik", SourceColumn: "Pemusik", Services: both}, // label sama dijumlah
+		{Role: "Kolektan 1", SourceColumn: "Kolektan", Services: both},
+		{Role: "Kolektan 2", SourceColumn: "Kolektan", Services: both},
+		{Role: "Kolektan 3", SourceColumn: "Kolektan", Services: both},
+	}
+}
+
+// Jumlah slot per role dari satu sumber (SlotsFor) untuk semua jalur.
+func TestSlotsFor(t *testing.T) {
+	d1 := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC)
+	cfg := NewConfig([]time.Time{d1, d2})
+	cfg.MaxLektor = 3
+	cfg.MaxPemusik = 2
+	cfg.Kolektan = Quota{Penatua: 2}
+	cfg.QuotaOn = map[string]map[string]Quota{"kolektan": {"2025-08-10": {Penatua: 2, Jemaat: 2}}}
+	maps := slotTestMaps()
+
+	cases := []struct {
+		role, svc string
+		d         time.Time
+		want      int
+	}{
+		{"Majelis Pendamping", "07", d1, 0},
+		{"Majelis Pendamping", "10", d1, 1},
+		{"Majelis Pendamping 07", "07", d1, 2},
+		{"Majelis Pendamping 07", "10", d1, 0},
+		{"Multimedia", "07", d1, 1},
+		{"Multimedia", "10", d1, 3},
+		{"Lektor 1", "07", d1, 1},
+		{"Lektor 3", "07", d1, 0}, // tidak berlaku di 07
+		{"Lektor 3", "10", d1, 1}, // -maxLektor 3, tiga baris di 10
+		{"Pemusik", "07", d1, 2},
+		{"Kolektan 2", "07", d1, 1},
+		{"Kolektan 3", "07", d1, 0}, // pola 2b: dua slot
+		{"Kolektan 3", "07", d2, 1}, // QuotaOn 2+2, dibatasi tiga baris
+		{"Tidak Ada", "07", d1, 0},
+	}
+	for _, tc := range cases {
+		if got := cfg.SlotsFor(tc.role, tc.svc, tc.d, maps); got != tc.want {
+			t.Errorf("SlotsFor(%q, %s, %s) = %d, ingin %d", tc.role, tc.svc, tc.d.Format("02-01"), got, tc.want)
+		}
+	}
+
+	cfg.MaxLektor = 2
+	if got := cfg.SlotsFor("Lektor 3", "10", d1, maps); got != 0 {
+		t.Errorf("-maxLektor 2: Lektor 3 = %d, ingin 0", got)
+	}
+
+	// batas per ibadah menimpa batas umum hanya di ibadah itu
+	cfg.MaxOn = map[string]map[string]int{"lektor": {"07": 1}}
+	if got := cfg.SlotsFor("Lektor 2", "07", d1, maps); got != 0 {
+		t.Errorf("MaxOn lektor 07=1: Lektor 2 di 07 = %d, ingin 0", got)
+	}
+	if got := cfg.SlotsFor("Lektor 2", "10", d1, maps); got != 1 {
+		t.Errorf("MaxOn lektor 07=1: Lektor 2 di 10 = %d, ingin 1", got)
+	}
+}
+
+// SlotPlan yang diisi GenerateSchedule harus sama dengan SlotsFor untuk setiap role.
+func TestSlotPlanMatchesSlotsFor(t *testing.T) {
+	d1 := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	d2 := time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC)
+	cfg := NewConfig([]time.Time{d1, d2})
+	cfg.MaxLektor = 3
+	cfg.QuotaOn = map[string]map[string]Quota{"kolektan": {"2025-08-10": {Penatua: 1, Jemaat: 2}}}
+	cfg.Plan = SlotPlan{}
+	maps := slotTestMaps()
+
+	if _, err := GenerateSchedule(cfg, nil, maps); err != nil {
+		t.Fatal(err)
+	}
+	for _, d := range cfg.Dates {
+		for _, svc := range []string{"07", "10"} {
+			for _, m := range maps {
+				want := cfg.SlotsFor(m.Role, svc, d, maps)
+				got, ok := cfg.Plan[d][svc][m.Role]
+				if !ok && want > 0 {
+					t.Errorf("%s %s %q: tidak ada di SlotPlan, SlotsFor = %d", d.Format("02-01"), svc, m.Role, want)
+					continue
+				}
+				if got != want {
+					t.Errorf("%s %s %q: SlotPlan %d, SlotsFor %d", d.Format("02-01"), svc, m.Role, got, want)
+				}
+			}
+		}
+	}
+}
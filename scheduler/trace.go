@@ -0,0 +1,105 @@
+package scheduler
+
+// Trace mengumpulkan alasan setiap pick (Config.Trace; nil = nonaktif),
+// urut sesuai jalannya generate sehingga dua run bisa di-diff.
+type Trace struct {
+	Entries []TraceEntry
+}
+
+// TraceEntry: satu orang yang ditugaskan ke satu slot.
+type TraceEntry struct {
+	Date    string `json:"date"` // yyyy-mm-dd
+	Service string `json:"service"`
+	Role    string `json:"role"`
+	Name    string `json:"name"`
+	// Stage: prefer, fallback-P/J, relax, relax-P/J, relax-any, relax-mp, pasangan, same-person, pin
+	Stage string `json:"stage"`
+	Pool  int    `json:"pool"` // jumlah kandidat yang diperiksa di fase ini
+	// kandidat yang dilewati sejak pick sebelumnya di fase yang sama
+	SkippedAssigned []string `json:"skippedAssigned,omitempty"` // sudah bertugas di ibadah ini/hari ini
+	SkippedBlocked  []string `json:"skippedBlocked,omitempty"`  // keluarga sama / Hindari / -noCrossService
+	SkippedB2B      []string `json:"skippedB2B,omitempty"`      // anti back-to-back (cooldown)
+}
+
+func (t *Trace) add(e TraceEntry) {
+	if t != nil {
+		t.Entries = append(t.Entries, e)
+	}
+}
+
+type skipReason int
+
+const (
+	skipAssigned skipReason = iota
+	skipBlocked
+	skipB2B
+)
+
+// skipped menampung kandidat yang dilewati; pointer nil (tanpa Trace) = no-op.
+type skipped struct {
+	lists [3][]string
+}
+
+func (s *skipped) add(r skipReason, name string) {
+	if s != nil {
+		s.lists[r] = append(s.lists[r], name)
+	}
+}
+
+// take mengembalikan daftar (assigned, blocked, b2b) lalu mengosongkannya.
+func (s *skipped) take() (assigned, blocked, b2b []string) {
+	if s == nil {
+		return nil, nil, nil
+	}
+	l := s.lists
+	s.lists = [3][]string{}
+	return l[skipAssigned], l[skipBlocked], l[skipB2B]
+}
+
+func newSkipped(t *Trace) *skipped {
+	if t == nil {
+		return nil
+	}
+	return &skipped{}
+}
+
+// pendingTrace: entri satu langkah pengisian, selaras dengan urutan picked;
+// role baru diketahui saat hasil dibagi ke baris role (flush).
+type pendingTrace struct {
+	t       *Trace
+	date    string
+	svc     string
+	entries []TraceEntry
+}
+
+func newPending(t *Trace, date, svc string) *pendingTrace {
+	if t == nil {
+		return nil
+	}
+	return &pendingTrace{t: t, date: date, svc: svc}
+}
+
+func (p *pendingTrace) rec(name, stage string, pool int, sk *skipped) {
+	if p == nil {
+		return
+	}
+	e := TraceEntry{Date: p.date, Service: p.svc, Name: name, Stage: stage, Pool: pool}
+	e.SkippedAssigned, e.SkippedBlocked, e.SkippedB2B = sk.take()
+	p.entries = append(p.entries, e)
+}
+
+// flush menambahkan n entri pertama ke Trace dengan role(i); sisanya
+// (pick yang terpotong kuota) dibuang.
+func (p *pendingTrace) flush(n int, role func(i int) string) {
+	if p == nil {
+		return
+	}
+	for i, e := range p.entries {
+		if i >= n {
+			break
+		}
+		e.Role = role(i)
+		p.t.add(e)
+	}
+	p.entries = nil
+}
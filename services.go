@@ -0,0 +1,134 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// serviceDef: label & jam tampil satu kunci ibadah (kunci "services" di -config).
+type serviceDef struct {
+	Label        string
+	Hour, Minute int
+}
+
+// serviceDefs kosong = perilaku lama: kunci 07 & 10, jam diambil dari kunci.
+var serviceDefs = map[string]serviceDef{}
+
+// parseServicesConfig membaca
+//
+//	services:
+//	  "07": {label: Pagi, time: "06:30"}
+//	  sore: {label: Sore, time: "18:00"}
+//
+// time wajib untuk kunci non-angka; kunci angka default ke jamnya sendiri.
+func parseServicesConfig(v interface{}) (map[string]serviceDef, error) {
+	entries := map[string]interface{}{}
+	switch m := v.(type) {
+	case map[string]interface{}:
+		for k, e := range m {
+			entries[k] = e
+		}
+	case map[interface{}]interface{}: // yaml: kunci 07 tanpa kutip terbaca angka
+		for k, e := range m {
+			entries[fmt.Sprint(k)] = e
+		}
+	default:
+		return nil, fmt.Errorf("services harus berupa map kunci -> {label, time}")
+	}
+
+	res := map[string]serviceDef{}
+	for k, e := range entries {
+		key := normServiceKey(k)
+		if key == "" {
+			return nil, fmt.Errorf("services: kunci kosong")
+		}
+		fields, ok := e.(map[string]interface{})
+		if !ok && e != nil {
+			return nil, fmt.Errorf("services %s: isi harus {label, time}", k)
+		}
+		for f := range fields {
+			if f != "label" && f != "time" {
+				return nil, fmt.Errorf("services %s: kunci tidak dikenal %q (pilih label, time)", k, f)
+			}
+		}
+		def := serviceDef{Label: strings.TrimSpace(fmt.Sprint(valueOr(fields["label"], "")))}
+		if t := strings.TrimSpace(fmt.Sprint(valueOr(fields["time"], ""))); t != "" {
+			h, m, err := parseClock(t)
+			if err != nil {
+				return nil, fmt.Errorf("services %s: %w", k, err)
+			}
+			def.Hour, def.Minute = h, m
+		} else if n, err := strconv.Atoi(key); err == nil {
+			def.Hour = n
+		} else {
+			return nil, fmt.Errorf("services %s: time wajib untuk kunci non-angka", k)
+		}
+		res[key] = def
+	}
+	return res, nil
+}
+
+func valueOr(v, def interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	return v
+}
+
+// parseClock: "06:30", "06.30", atau "18" -> jam, menit.
+func parseClock(s string) (int, int, error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool { return r == ':' || r == '.' })
+	if len(parts) == 0 || len(parts) > 2 {
+		return 0, 0, fmt.Errorf("jam '%s' tidak valid (pakai HH:MM)", s)
+	}
+	h, err := strconv.Atoi(parts[0])
+	m := 0
+	if err == nil && len(parts) == 2 {
+		m, err = strconv.Atoi(parts[1])
+	}
+	if err != nil || h < 0 || h > 23 || m < 0 || m > 59 {
+		return 0, 0, fmt.Errorf("jam '%s' tidak valid (pakai HH:MM)", s)
+	}
+	return h, m, nil
+}
+
+// configuredServiceKeys: kunci di serviceDefs terurut jam (nil bila tidak dikonfigurasi).
+func configuredServiceKeys() []string {
+	var keys []string
+	for k := range serviceDefs {
+		keys = append(keys, k)
+	}
+	sortByClock(keys)
+	return keys
+}
+
+// serviceClock: jam mulai ibadah (konfigurasi, atau dari kunci "07" -> 07:00).
+func serviceClock(svc string) (int, int) {
+	if def, ok := serviceDefs[svc]; ok {
+		return def.Hour, def.Minute
+	}
+	return atoiSafe(svc), 0
+}
+
+// serviceName untuk output: "Pagi 06.30" bila dikonfigurasi, selain itu
+// "07.00" seperti sebelumnya. sep memisahkan jam & menit ("." atau ":").
+func serviceName(svc, sep string) string {
+	def, ok := serviceDefs[svc]
+	if !ok {
+		return svc + sep + "00"
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %02d%s%02d", def.Label, def.Hour, sep, def.Minute))
+}
+
+func sortByClock(keys []string) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		hi, mi := serviceClock(keys[i])
+		hj, mj := serviceClock(keys[j])
+		if hi*60+mi != hj*60+mj {
+			return hi*60+mi < hj*60+mj
+		}
+		return keys[i] < keys[j]
+	})
+}
@@ -0,0 +1,387 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// findTemplate mencari file template di folder kerja lalu folder executable.
+func findTemplate(exeDir, name string) (string, error) {
+	var tried []string
+	if filepath.IsAbs(name) {
+		tried = []string{name}
+	} else {
+		cwd, _ := os.Getwd()
+		tried = []string{filepath.Join(cwd, name)}
+		if exe := filepath.Join(exeDir, name); exe != tried[0] {
+			tried = append(tried, exe)
+		}
+	}
+	for _, p := range tried {
+		if st, err := os.Stat(p); err == nil && !st.IsDir() {
+			return p, nil
+		}
+	}
+	return "", fmt.Errorf("template %s tidak ditemukan (dicari: %s); taruh file template di sana atau pakai -autoTemplate",
+		name, joinQuoted(tried))
+}
+
+func joinQuoted(ss []string) string {
+	res := ""
+	for i, s := range ss {
+		if i > 0 {
+			res += ", "
+		}
+		res += fmt.Sprintf("%q", s)
+	}
+	return res
+}
+
+// writeDefaultTemplate membuat template minimal (-autoTemplate): baris 1 berisi
+// placeholder tanggal di kolom -startColumn sebanyak -dateColumns, kolom A
+// berisi label role MappingRole (unik, urutan sheet).
+func writeDefaultTemplate(maps []RoleMap, sheetName, path string) error {
+	firstCol, err := templateDateColumns()
+	if err != nil {
+		return err
+	}
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", sheetName); err != nil {
+		return err
+	}
+	_ = f.SetCellStr(sheetName, "A1", "Role")
+	for i := 0; i < *dateColsFlag; i++ {
+		_ = f.SetCellStr(sheetName, cell(firstCol+i, 1), "{Day}, {dd} {MMMM} {yyyy}")
+	}
+	row := 2
+	seen := map[string]bool{}
+	for _, m := range maps {
+		if seen[m.Role] {
+			continue
+		}
+		seen[m.Role] = true
+		_ = f.SetCellStr(sheetName, cell(1, row), m.Role)
+		row++
+	}
+	_ = f.SetColWidth(sheetName, "A", "A", 24)
+	return f.SaveAs(path)
+}
+
+// writeGenTemplate: -genTemplate. Tidak menimpa template yang sudah ada.
+func writeGenTemplate(maps []RoleMap, path, sheetName string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s sudah ada; hapus/ganti nama dulu atau pakai -template nama lain", path)
+	}
+	lastHeader, err := buildTemplate(maps, sheetName, path)
+	if err != nil {
+		return fmt.Errorf("membuat template: %w", err)
+	}
+	if lastHeader > *headerRowsFlag {
+		infof("INFO: header terakhir di baris %d; jalankan dengan -headerRows %d\n", lastHeader, lastHeader)
+	}
+	success(path)
+	return nil
+}
+
+// buildTemplate menulis template lengkap: satu blok per ibadah
+// (judul, baris header WAKTU + placeholder tanggal & jam, lalu role-role
+// ibadah itu dari MappingRole), dipisah satu baris kosong. Role yang muncul
+// di lebih dari satu blok diberi sufiks "[svc]". Mengembalikan
+// baris header terakhir agar bisa dicek terhadap -headerRows.
+func buildTemplate(maps []RoleMap, sheetName, path string) (int, error) {
+	firstCol, err := templateDateColumns()
+	if err != nil {
+		return 0, err
+	}
+	f := excelize.NewFile()
+	defer f.Close()
+	if err := f.SetSheetName("Sheet1", sheetName); err != nil {
+		return 0, err
+	}
+	bold, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	header, _ := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Alignment: &excelize.Alignment{WrapText: true, Horizontal: "center", Vertical: "center"},
+	})
+	lastCol, _ := excelize.ColumnNumberToName(firstCol + *dateColsFlag - 1)
+
+	services := scheduler.ServiceKeys(maps, configuredServiceKeys())
+	blocks := map[string][]string{}
+	inBlocks := map[string]int{} // role -> jumlah blok yang memuatnya
+	for _, svc := range services {
+		seen := map[string]bool{}
+		for _, m := range maps {
+			if scheduler.InService(m, svc) && !seen[m.Role] {
+				seen[m.Role] = true
+				blocks[svc] = append(blocks[svc], m.Role)
+				inBlocks[m.Role]++
+			}
+		}
+	}
+
+	row, lastHeader := 1, 0
+	for _, svc := range services {
+		roles := blocks[svc]
+		if len(roles) == 0 {
+			continue // mis. default "10" tanpa role di MappingRole
+		}
+		if row > 1 {
+			row++ // baris kosong antar blok
+		}
+		_ = f.SetCellStr(sheetName, cell(1, row), strings.ToUpper("Ibadah "+serviceName(svc, ".")))
+		_ = f.SetCellStyle(sheetName, cell(1, row), cell(1, row), bold)
+		row++
+
+		h, m := serviceClock(svc)
+		_ = f.SetCellStr(sheetName, cell(1, row), "WAKTU")
+		for i := 0; i < *dateColsFlag; i++ {
+			_ = f.SetCellStr(sheetName, cell(firstCol+i, row),
+				fmt.Sprintf("{Day}, {dd} {MMMM} {yyyy}\nPkl. %02d.%02d Wib", h, m))
+		}
+		_ = f.SetCellStyle(sheetName, cell(1, row), cell(firstCol+*dateColsFlag-1, row), header)
+		_ = f.SetRowHeight(sheetName, row, 32)
+		lastHeader = row
+		row++
+
+		for _, role := range roles {
+			if inBlocks[role] > 1 {
+				role += " [" + svc + "]" // lihat roleRowIndex.row
+			}
+			_ = f.SetCellStr(sheetName, cell(1, row), role)
+			row++
+		}
+	}
+	if lastHeader == 0 {
+		return 0, errors.New("MappingRole tidak punya role untuk ibadah mana pun")
+	}
+	_ = f.SetColWidth(sheetName, "A", "A", 24)
+	startName, _ := excelize.ColumnNumberToName(firstCol)
+	_ = f.SetColWidth(sheetName, startName, lastCol, 28)
+	return lastHeader, f.SaveAs(path)
+}
+
+// splitServiceLabel: "Lektor [07]" -> ("Lektor", "07"); tanpa sufiks tag "".
+func splitServiceLabel(label string) (role, tag string) {
+	lab := strings.TrimSpace(label)
+	if strings.HasSuffix(lab, "]") {
+		if i := strings.LastIndex(lab, "["); i > 0 {
+			return strings.TrimSpace(lab[:i]), strings.TrimSpace(lab[i+1 : len(lab)-1])
+		}
+	}
+	return lab, ""
+}
+
+// serviceTagMatches: sufiks label cocok dengan kunci ibadah ("07"), jamnya
+// ("07.00") atau label konfigurasi ("Pagi").
+func serviceTagMatches(tag, svc string) bool {
+	if strings.EqualFold(tag, svc) || tag == serviceName(svc, ".") || tag == serviceName(svc, ":") {
+		return true
+	}
+	def, ok := serviceDefs[svc]
+	return ok && def.Label != "" && strings.EqualFold(tag, def.Label)
+}
+
+// stripServiceLabels membuang sufiks ibadah dari label kolom A di output
+// (baris dari ix.tagged).
+func stripServiceLabels(f *excelize.File, sheet string, ix *roleRowIndex) {
+	for _, row := range ix.tagged {
+		v, _ := f.GetCellValue(sheet, cell(1, row))
+		if lab, tag := splitServiceLabel(v); tag != "" {
+			_ = f.SetCellStr(sheet, cell(1, row), lab)
+		}
+	}
+}
+
+// finishCombined (-combine): buang sheet template asli, sheet bulan pertama
+// (firstSheet) jadi sheet aktif.
+func finishCombined(path, sheetName, firstSheet string) error {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if sheet := findSheet(f, []string{sheetName}); sheet != "" {
+		if err := f.DeleteSheet(sheet); err != nil {
+			return err
+		}
+	}
+	if idx, err := f.GetSheetIndex(firstSheet); err == nil && idx >= 0 {
+		f.SetActiveSheet(idx)
+	}
+	return f.Save()
+}
+
+// templateLimit: satu batas grup (-maxLektor dst) yang dicek terhadap template;
+// perSvc = batas khusus ibadah (-maxLektorService dst) yang menimpa max.
+type templateLimit struct {
+	group, flag string
+	max         *int
+	perSvc      map[string]int
+}
+
+// checkTemplateCapacity membandingkan batas grup dengan baris yang benar-benar
+// ada di template per ibadah. Nama yang dipilih untuk baris MappingRole tanpa
+// baris template tidak pernah tertulis, jadi beri WARN; dengan clampTo batas
+// diturunkan ke kapasitas terkecil (minimal 1).
+func checkTemplateCapacity(path, sheetName string, maps []RoleMap, limits []templateLimit, clampTo bool) error {
+	f, err := excelize.OpenFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sheet, err := requireSheet(f, sheetName)
+	if err != nil {
+		return fmt.Errorf("template %s: %w", path, err)
+	}
+	sheetRows, _ := f.GetRows(sheet)
+	ix := newRoleRowIndex(sheetRows)
+	for _, l := range limits {
+		capacity := 0
+		for _, svc := range scheduler.ServiceKeys(maps, configuredServiceKeys()) {
+			rows, inTemplate := 0, 0
+			for _, m := range maps {
+				if scheduler.BaseRole(m.Role) != l.group || !scheduler.InService(m, svc) {
+					continue
+				}
+				rows++
+				if ix.row(m.Role, svc) >= 1 {
+					inTemplate++
+				}
+			}
+			limit, label := *l.max, fmt.Sprintf("%s=%d", l.flag, *l.max)
+			n, override := l.perSvc[svc]
+			if override {
+				limit, label = n, fmt.Sprintf("%sService %s=%d", l.flag, svc, n)
+			}
+			want := limit
+			if rows < want {
+				want = rows // scheduler juga dibatasi jumlah baris MappingRole
+			}
+			if want <= inTemplate {
+				continue
+			}
+			infof("WARN: %s tapi template hanya punya %d baris %s di ibadah %s; nama ke-%d dst tidak akan tertulis\n",
+				label, inTemplate, strings.Title(l.group), serviceName(svc, "."), inTemplate+1)
+			if inTemplate == 0 {
+				continue
+			}
+			if override {
+				if clampTo {
+					infof("INFO: %sService %s diturunkan ke %d (-clampToTemplate)\n", l.flag, svc, inTemplate)
+					l.perSvc[svc] = inTemplate
+				}
+				continue
+			}
+			if capacity == 0 || inTemplate < capacity {
+				capacity = inTemplate
+			}
+		}
+		if clampTo && capacity > 0 {
+			infof("INFO: %s diturunkan ke %d (-clampToTemplate)\n", l.flag, capacity)
+			*l.max = capacity
+		}
+	}
+	return nil
+}
+
+// ==================== Indeks baris role & cache template ====================
+
+// labelRow: satu baris berlabel di kolom A (nomor baris 1-based).
+type labelRow struct {
+	row int
+	tag string // sufiks ibadah ("07", "Pagi"), kosong = label polos
+}
+
+// roleRowIndex: baris role per label (huruf kecil, tanpa sufiks ibadah) dari
+// satu sheet, dibangun sekali dari GetRows lalu dicari tanpa membaca sheet lagi.
+type roleRowIndex struct {
+	byLabel map[string][]labelRow // urut baris
+	mp      []labelRow            // label mirip Majelis Pendamping (cadangan fuzzy)
+	tagged  []int                 // baris bersufiks ibadah (dibuang di output)
+}
+
+func newRoleRowIndex(rows [][]string) *roleRowIndex {
+	ix := &roleRowIndex{byLabel: map[string][]labelRow{}}
+	for i, r := range rows {
+		if len(r) == 0 {
+			continue
+		}
+		lab, tag := splitServiceLabel(r[0])
+		key := strings.ToLower(lab)
+		lr := labelRow{row: i + 1, tag: tag}
+		ix.byLabel[key] = append(ix.byLabel[key], lr)
+		if strings.Contains(key, "majel") && strings.Contains(key, "pend") {
+			ix.mp = append(ix.mp, lr)
+		}
+		if tag != "" {
+			ix.tagged = append(ix.tagged, i+1)
+		}
+	}
+	return ix
+}
+
+// row: baris role untuk ibadah svc, -1 bila tidak ada. Label bersufiks ibadah
+// ("Lektor [07]", "Lektor [Pagi]") didahulukan; label polos jadi cadangan,
+// sufiks ibadah lain dilewati. Jadi role yang sama di 07 & 10 bisa beda baris.
+// Majelis Pendamping tanpa label persis: label yang memuat "majel" & "pend".
+func (ix *roleRowIndex) row(role, svc string) int {
+	match := func(cands []labelRow) int {
+		plain := -1
+		for _, lr := range cands {
+			if lr.tag == "" {
+				if plain < 0 {
+					plain = lr.row
+				}
+			} else if serviceTagMatches(lr.tag, svc) {
+				return lr.row
+			}
+		}
+		return plain
+	}
+	if row := match(ix.byLabel[strings.ToLower(strings.TrimSpace(role))]); row > 0 {
+		return row
+	}
+	if scheduler.IsMajelisPendamping(role) {
+		return match(ix.mp)
+	}
+	return -1
+}
+
+// templateCache (-months): isi file template & indeks baris sheet jadwalnya
+// dibaca sekali lalu dipakai untuk setiap bulan. Hanya dibaca setelah dibuat,
+// jadi aman dipakai bersama oleh penulis paralel (-jobs).
+type templateCache struct {
+	path  string
+	data  []byte
+	index *roleRowIndex
+}
+
+func loadTemplateCache(path, sheetName string) (*templateCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	sheet, err := requireSheet(f, sheetName)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := f.GetRows(sheet)
+	if err != nil {
+		return nil, err
+	}
+	return &templateCache{path: path, data: data, index: newRoleRowIndex(rows)}, nil
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xuri/excelize/v2"
+)
+
+func TestRoleRowIndex(t *testing.T) {
+	rows := [][]string{
+		{"UMUM"},
+		{"Lektor 1"},
+		{"lektor 2 [07]"},
+		{"Lektor 2"},
+		{"Lektor 2 [10]"},
+		{},
+		{"Majels Pendamping"},
+	}
+	ix := newRoleRowIndex(rows)
+	cases := []struct {
+		role, svc string
+		want      int
+	}{
+		{"Lektor 1", "07", 2},
+		{"LEKTOR 1", "10", 2},
+		{"Lektor 2", "07", 3}, // sufiks ibadah didahulukan
+		{"Lektor 2", "10", 5},
+		{"Lektor 2", "17", 4}, // sufiks lain dilewati, label polos jadi cadangan
+		{"Majelis Pendamping", "10", 7},
+		{"Pemusik", "07", -1},
+	}
+	for _, tc := range cases {
+		if got := ix.row(tc.role, tc.svc); got != tc.want {
+			t.Errorf("row(%q, %s) = %d, ingin %d", tc.role, tc.svc, got, tc.want)
+		}
+	}
+	if want := []int{3, 5}; fmt.Sprint(ix.tagged) != fmt.Sprint(want) {
+		t.Errorf("tagged = %v, ingin %v", ix.tagged, want)
+	}
+}
+
+// Mencari baris role untuk satu bulan (5 tanggal x 2 ibadah x semua role)
+// di template 60 baris: sebelumnya GetRows sekali per pencarian, sekarang
+// sekali per sheet. Metrik GetRows/op menunjukkan jumlah pembacaan sheet.
+func BenchmarkRoleRowLookup(b *testing.B) {
+	f := excelize.NewFile()
+	defer f.Close()
+	const sheet = "Sheet1"
+	var roles []string
+	for i := 1; i <= 60; i++ {
+		role := fmt.Sprintf("Role %d", i)
+		roles = append(roles, role)
+		_ = f.SetCellStr(sheet, cell(1, i), role)
+		_ = f.SetCellStr(sheet, cell(2, i), "{tanggal}")
+	}
+	lookups := func(row func(role, svc string) int) {
+		for d := 0; d < 5; d++ {
+			for _, svc := range []string{"07", "10"} {
+				for _, role := range roles {
+					if row(role, svc) < 1 {
+						b.Fatalf("%s tidak ditemukan", role)
+					}
+				}
+			}
+		}
+	}
+
+	b.Run("GetRowsPerRole", func(b *testing.B) {
+		calls := 0
+		for i := 0; i < b.N; i++ {
+			lookups(func(role, svc string) int {
+				rows, _ := f.GetRows(sheet)
+				calls++
+				return newRoleRowIndex(rows).row(role, svc)
+			})
+		}
+		b.ReportMetric(float64(calls)/float64(b.N), "GetRows/op")
+	})
+	b.Run("Index", func(b *testing.B) {
+		calls := 0
+		for i := 0; i < b.N; i++ {
+			rows, _ := f.GetRows(sheet)
+			calls++
+			ix := newRoleRowIndex(rows)
+			lookups(ix.row)
+		}
+		b.ReportMetric(float64(calls)/float64(b.N), "GetRows/op")
+	})
+}
+
+// {Label}: isi -labelDates untuk tanggal itu; tanpa label, hanya baris
+// {Label} yang dirapikan (baris lain utuh).
+func TestReplacePlaceholdersLabel(t *testing.T) {
+	d := time.Date(2025, 9, 7, 0, 0, 0, 0, time.UTC)
+	cases := []struct{ in, label, want string }{
+		{"{Day}, {dd} {MMMM} {yyyy}\n{Label}", "Perjamuan Kudus", "Minggu, 07 September 2025\nPerjamuan Kudus"},
+		{"{Day}, {dd} {MMMM} {yyyy}\n{Label}", "", "Minggu, 07 September 2025"},
+		{"{Label}", "", ""},
+		{"{dd} {MMMM} {Label}\nPkl. 07.00", "", "07 September\nPkl. 07.00"},
+		{"{dd} {MMMM}", "Baptisan", "07 September"},
+		// sel multi-baris: hanya baris {Label} yang dirapikan/dihapus
+		{"  {Day}, {dd} {MMMM}\n\n{Label}\nPkl. 07.00 ", "", "  Minggu, 07 September\n\nPkl. 07.00 "},
+		{"{Day}\n  {Label}  \n\tPkl. 07.00", "", "Minggu\n\tPkl. 07.00"},
+	}
+	for _, c := range cases {
+		if got := replacePlaceholders(c.in, d, time.UTC, c.label); got != c.want {
+			t.Errorf("replacePlaceholders(%q, %q) = %q, ingin %q", c.in, c.label, got, c.want)
+		}
+	}
+
+	dl := dateLabels{}
+	for _, v := range []string{"2025-09-07=Perjamuan Kudus, Baptisan", "2025-09-14 = Syukur"} {
+		if err := dl.Set(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got, want := dl.String(), "2025-09-07=Perjamuan Kudus, Baptisan,2025-09-14=Syukur"; got != want {
+		t.Errorf("String() = %q, ingin %q", got, want)
+	}
+	for _, bad := range []string{"Perjamuan", "07-09-2025=Perjamuan"} {
+		if err := dl.Set(bad); err == nil {
+			t.Errorf("Set(%q) harus error", bad)
+		}
+	}
+}
+
+// Token tanggal satu per satu, lalu gabungan dalam satu sel; {MMM} tetap
+// nama lengkap (template lama), singkatan lewat {MonShort}.
+func TestReplacePlaceholdersDateTokens(t *testing.T) {
+	d := time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC)
+	cases := []struct{ in, want string }{
+		{"{Day}", "Minggu"},
+		{"{DayShort}", "Min"},
+		{"{dd}", "03"},
+		{"{d}", "3"},
+		{"{MMMM}", "Agustus"},
+		{"{MMM}", "Agustus"},
+		{"{MonShort}", "Agu"},
+		{"{m}", "8"},
+		{"{yyyy}", "2025"},
+		{"{yy}", "25"},
+		{"{DayShort}, {d}/{m}/{yy}", "Min, 3/8/25"},
+		{"{Day}, {dd} {MMMM} {yyyy} ({MonShort} {d})\nPkl. 07.00", "Minggu, 03 Agustus 2025 (Agu 3)\nPkl. 07.00"},
+		{"{x} {DD}", "{x} {DD}"}, // token tak dikenal dibiarkan
+	}
+	for _, c := range cases {
+		if got := replacePlaceholders(c.in, d, time.UTC, ""); got != c.want {
+			t.Errorf("replacePlaceholders(%q) = %q, ingin %q", c.in, got, c.want)
+		}
+	}
+
+	saved := *langFlag
+	defer func() { *langFlag = saved }()
+	*langFlag = "en"
+	if got, want := replacePlaceholders("{DayShort} {d} {MonShort} {yy}", d, time.UTC, ""), "Sun 3 Aug 25"; got != want {
+		t.Errorf("-lang en: %q, ingin %q", got, want)
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"jadwal-petugas-cli/scheduler"
+)
+
+// validateMaster memeriksa kecocokan MappingRole dengan Petugas tanpa
+// menulis file apa pun. Mengembalikan error bila ada masalah berat.
+func validateMaster(people []Person, maps []RoleMap) error {
+	headers := map[string]bool{}
+	names := map[string]bool{}
+	for _, p := range people {
+		names[p.Name] = true
+		for k := range p.Marks {
+			headers[k] = true
+		}
+	}
+
+	var errs, warns []string
+	for _, p := range people {
+		if p.Partner != "" && !names[p.Partner] {
+			warns = append(warns, fmt.Sprintf("petugas %q: pasangan %q tidak ada di kolom Nama", p.Name, p.Partner))
+		}
+		for _, a := range p.Avoid {
+			if !names[a] {
+				warns = append(warns, fmt.Sprintf("petugas %q: nama Hindari %q tidak ada di kolom Nama", p.Name, a))
+			}
+		}
+	}
+	// kolom Petugas yang tidak dirujuk role mana pun (kolom mati);
+	// kebalikannya sudah tercakup error "tidak ada di sheet Petugas" di bawah
+	var hdrs []string
+	for k := range headers {
+		hdrs = append(hdrs, k)
+	}
+	sort.Strings(hdrs)
+	unused, _ := orphanColumns(hdrs, maps)
+	for _, h := range unused {
+		warns = append(warns, fmt.Sprintf("kolom Petugas %q tidak dipakai role mana pun di MappingRole", h))
+	}
+	for _, m := range maps {
+		if !headers[scheduler.NormKey(m.SourceColumn)] {
+			errs = append(errs, fmt.Sprintf("role %q: kolom %q tidak ada di sheet Petugas", m.Role, m.SourceColumn))
+			continue
+		}
+		n := len(scheduler.FilterCandidates(people, m.SourceColumn, false, time.Time{}))
+		if n == 0 {
+			errs = append(errs, fmt.Sprintf("role %q: tidak ada petugas yang ditandai di kolom %q", m.Role, m.SourceColumn))
+			continue
+		}
+		if scheduler.IsMajelisPendamping(m.Role) {
+			if pen := len(scheduler.FilterCandidates(people, m.SourceColumn, true, time.Time{})); pen == 0 {
+				warns = append(warns, fmt.Sprintf("role %q: tidak ada Penatua eligible (Majelis Pendamping wajib Penatua)", m.Role))
+			}
+		}
+	}
+
+	infof("Validasi Master: %d petugas, %d role\n", len(people), len(maps))
+	for _, e := range errs {
+		infoln("  ERROR:", e)
+	}
+	for _, w := range warns {
+		infoln("  WARN: ", w)
+	}
+	infof("Hasil: %d error, %d peringatan\n", len(errs), len(warns))
+	if len(errs) > 0 {
+		return fmt.Errorf("validasi gagal: %d error", len(errs))
+	}
+	return nil
+}
+
+// metaColumns: kolom Petugas yang dibaca loadMaster sendiri (bukan eligibility).
+var metaColumns = map[string]bool{
+	"no": true, "nama": true, "penatua": true, "tidakbisa": true, "tidak bisa": true,
+	"keluarga": true, "pasangan": true, "hindari": true,
+}
+
+// orphanColumns membandingkan header Petugas dengan Kolom Master di MappingRole
+// (via NormKey): unused = header yang tidak dirujuk role mana pun, unknown =
+// Kolom Master yang tidak ada di header. Urutan mengikuti input, tanpa duplikat.
+func orphanColumns(headers []string, maps []RoleMap) (unused, unknown []string) {
+	have := map[string]bool{}
+	for _, h := range headers {
+		have[scheduler.NormKey(h)] = true
+	}
+	used := map[string]bool{}
+	seen := map[string]bool{}
+	for _, m := range maps {
+		k := scheduler.NormKey(m.SourceColumn)
+		used[k] = true
+		if !have[k] && !seen[k] {
+			seen[k] = true
+			unknown = append(unknown, m.SourceColumn)
+		}
+	}
+	for _, h := range headers {
+		k := scheduler.NormKey(h)
+		if k == "" || used[k] || metaColumns[k] || seen[k] {
+			continue
+		}
+		seen[k] = true
+		unused = append(unused, h)
+	}
+	return unused, unknown
+}